@@ -0,0 +1,194 @@
+package sorter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+	"wb-level-2/develop/dev03/utils"
+)
+
+// keySpan границы части строки (в байтах), входящей в ключ сортировки
+type keySpan struct {
+	start int
+	end   int
+}
+
+// fieldSpans возвращает границы колонок строки, разделенных пробельными символами, аналогично strings.Fields
+func fieldSpans(line string) []keySpan {
+	var spans []keySpan
+
+	start := -1
+
+	for i, r := range line {
+		switch {
+		case unicode.IsSpace(r) && start >= 0:
+			spans = append(spans, keySpan{start: start, end: i})
+			start = -1
+		case !unicode.IsSpace(r) && start < 0:
+			start = i
+		}
+	}
+
+	if start >= 0 {
+		spans = append(spans, keySpan{start: start, end: len(line)})
+	}
+
+	return spans
+}
+
+// keySpans метод, возвращающий границы частей строки, из которых метод key составляет ключ сортировки
+func (s *Sorter) keySpans(line string) []keySpan {
+	// учитывание опции Columns
+	if len(s.options.Columns) > 0 {
+		fields := fieldSpans(line)
+
+		var spans []keySpan
+
+		for _, fieldNumber := range s.options.Columns {
+			if fieldNumber >= 1 && fieldNumber <= len(fields) {
+				spans = append(spans, fields[fieldNumber-1])
+			}
+		}
+
+		return spans
+	}
+
+	// учитывание опции IgnoreLeadingBlanks
+	return []keySpan{{start: len(line) - len(s.trimKey(line)), end: len(line)}}
+}
+
+// underline возвращает строку подчеркивания частей line, заданных spans: символы ключа заменяются на '_', остальные
+// на пробелы, табуляции сохраняются для выравнивания. Если ключ пуст, возвращается предупреждение
+func underline(line string, spans []keySpan) string {
+	var builder strings.Builder
+
+	position := 0
+	empty := true
+
+	for _, span := range spans {
+		for _, r := range line[position:span.start] {
+			if r == '\t' {
+				builder.WriteRune('\t')
+			} else {
+				builder.WriteRune(' ')
+			}
+		}
+
+		builder.WriteString(strings.Repeat("_", utf8.RuneCountInString(line[span.start:span.end])))
+
+		position = span.end
+		empty = empty && span.start == span.end
+	}
+
+	if empty {
+		return "^ no match for key"
+	}
+
+	return builder.String()
+}
+
+// keyWarning метод, возвращающий предупреждение, если ключ не удалось разобрать с учетом опций типа сравнения и он
+// сравнивается как текст, иначе пустую строку
+func (s *Sorter) keyWarning(key string) string {
+	var err error
+
+	switch {
+	case s.options.Random:
+		return ""
+	case s.options.TimeKey:
+		_, err = utils.ParseTime(key, s.options.TimeLayout)
+		if err != nil && s.options.TimeUnparsedFirst {
+			return "^ no time, sorted first"
+		}
+
+		if err != nil {
+			return "^ no time, sorted last"
+		}
+	case s.options.GeneralNumeric:
+		_, err = utils.ParseGeneralNumericValue(key)
+		if err != nil {
+			return "^ not a number, sorted before numbers"
+		}
+	case s.options.NumericSuffix:
+		_, _, err = utils.ParseHumanSize(key, s.options.SI)
+	case s.options.Numeric:
+		_, err = utils.ParseNumericValue(key, false)
+	case s.options.Month:
+		_, err = utils.ParseMonth(key)
+		if err != nil {
+			return "^ no month name, compared as text"
+		}
+	}
+
+	if err != nil {
+		return "^ no number, compared as text"
+	}
+
+	return ""
+}
+
+// debugLines метод, возвращающий аннотации строки для отладки: подчеркивание ключа сортировки и предупреждения для
+// текстового формата, значения ключей для CSV и JSON Lines
+func (s *Sorter) debugLines(line string) []string {
+	var result []string
+
+	switch s.options.Format {
+	case FormatCSV:
+		fields := decodeCSV(line)
+
+		for _, index := range s.csvIndexes(len(fields)) {
+			key := s.trimKey(csvField(fields, index))
+			result = append(result, fmt.Sprintf("^ column %d: %q", index+1, key))
+
+			if warning := s.keyWarning(key); warning != "" {
+				result = append(result, warning)
+			}
+		}
+	case FormatJSONL:
+		for _, value := range s.jsonKeys(line) {
+			if value.rank == jsonMissing {
+				result = append(result, "^ no match for key")
+				continue
+			}
+
+			result = append(result, fmt.Sprintf("^ key: %s", value.text))
+		}
+	default:
+		result = append(result, underline(line, s.keySpans(line)))
+
+		if warning := s.keyWarning(s.key(line)); warning != "" {
+			result = append(result, warning)
+		}
+	}
+
+	return result
+}
+
+// Debug метод, записывающий в writer строки lines (как правило, уже отсортированные), после каждой из которых
+// выводится подчеркивание частей строки, составляющих ключ сортировки, и предупреждения о ключах, которые не удалось
+// разобрать как число или месяц и которые поэтому сравниваются как текст. Строки и аннотации разделяются переводом
+// строки независимо от опции ZeroTerminated. Заголовок CSV выводится без аннотаций
+func (s *Sorter) Debug(writer io.Writer, lines []string) error {
+	bufferedWriter := bufio.NewWriter(writer)
+
+	for i, line := range lines {
+		output := []string{line}
+
+		switch {
+		case i == 0 && s.hasHeader():
+			_ = s.setHeader(line)
+		default:
+			output = append(output, s.debugLines(line)...)
+		}
+
+		err := utils.WriteData(bufferedWriter, output...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return bufferedWriter.Flush()
+}
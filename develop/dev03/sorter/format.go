@@ -0,0 +1,388 @@
+package sorter
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"wb-level-2/develop/dev03/utils"
+)
+
+// Format формат входных данных
+type Format string
+
+const (
+	// FormatText текст, записи разделены переводом строки (или нулевым байтом), ключи - колонки, разделенные
+	// пробельными символами
+	FormatText Format = "text"
+	// FormatCSV CSV по RFC 4180, первая запись - заголовок, ключи - имена или номера колонок
+	FormatCSV Format = "csv"
+	// FormatJSONL JSON Lines, каждая строка - JSON-значение, ключи - JSON pointer (RFC 6901), например /user/id
+	FormatJSONL Format = "jsonl"
+)
+
+var (
+	// ErrUnknownFormat ошибка, возвращаемая при неизвестном формате входных данных
+	ErrUnknownFormat = errors.New("unknown format")
+	// ErrUnknownColumn ошибка, возвращаемая, если в заголовке CSV нет колонки, указанной в ключах
+	ErrUnknownColumn = errors.New("unknown column")
+)
+
+// ParseFormat принимает на вход название формата, возвращает Format или ErrUnknownFormat
+func ParseFormat(name string) (Format, error) {
+	switch format := Format(name); format {
+	case "", FormatText:
+		return FormatText, nil
+	case FormatCSV, FormatJSONL:
+		return format, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownFormat, name)
+	}
+}
+
+// recordScanner интерфейс для чтения входных данных по записям, которому удовлетворяет bufio.Scanner
+type recordScanner interface {
+	Scan() bool
+	Text() string
+	Err() error
+}
+
+// csvScanner читает CSV-записи, каждая запись возвращается в виде одной строки в формате CSV, которая может
+// содержать перевод строки внутри поля в кавычках
+type csvScanner struct {
+	reader *csv.Reader
+	text   string
+	err    error
+}
+
+// Scan читает следующую запись, возвращает false при окончании данных или ошибке
+func (cs *csvScanner) Scan() bool {
+	if cs.err != nil {
+		return false
+	}
+
+	fields, err := cs.reader.Read()
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			cs.err = err
+		}
+
+		return false
+	}
+
+	cs.text, cs.err = encodeCSV(fields)
+
+	return cs.err == nil
+}
+
+// Text возвращает последнюю прочитанную запись
+func (cs *csvScanner) Text() string { return cs.text }
+
+// Err возвращает первую ошибку чтения, кроме io.EOF
+func (cs *csvScanner) Err() error { return cs.err }
+
+// encodeCSV возвращает поля записи в виде строки в формате CSV без завершающего перевода строки
+func encodeCSV(fields []string) (string, error) {
+	var buffer bytes.Buffer
+
+	writer := csv.NewWriter(&buffer)
+
+	err := writer.Write(fields)
+	if err != nil {
+		return "", err
+	}
+
+	writer.Flush()
+
+	return strings.TrimSuffix(buffer.String(), "\n"), writer.Error()
+}
+
+// decodeCSV возвращает поля записи, закодированной encodeCSV, в случае ошибки - nil
+func decodeCSV(record string) []string {
+	reader := csv.NewReader(strings.NewReader(record))
+	reader.FieldsPerRecord = -1
+
+	fields, err := reader.Read()
+	if err != nil {
+		return nil
+	}
+
+	return fields
+}
+
+// newScanner метод, возвращающий объект для чтения записей input в соответствии с форматом
+func (s *Sorter) newScanner(input io.Reader) recordScanner {
+	if s.options.Format == FormatCSV {
+		reader := csv.NewReader(input)
+		reader.FieldsPerRecord = -1
+
+		return &csvScanner{reader: reader}
+	}
+
+	return utils.NewScanner(input, s.delimiter())
+}
+
+// hasHeader метод, проверяющий, является ли первая запись данных заголовком
+func (s *Sorter) hasHeader() bool {
+	return s.options.Format == FormatCSV && !s.options.NoHeader
+}
+
+// setHeader метод, сохраняющий заголовок CSV для поиска колонок по имени, возвращает ErrUnknownColumn, если
+// колонки, указанной в ключах, в заголовке нет
+func (s *Sorter) setHeader(header string) error {
+	s.header = decodeCSV(header)
+
+	for _, key := range s.options.Keys {
+		if s.columnIndex(key) == -1 {
+			return fmt.Errorf("%w: %s", ErrUnknownColumn, key)
+		}
+	}
+
+	return nil
+}
+
+// columnIndex метод, возвращающий индекс колонки CSV (начиная с 0) по номеру колонки (начиная с 1) или имени из
+// заголовка, -1 - если колонка не найдена
+func (s *Sorter) columnIndex(key string) int {
+	if number, err := strconv.Atoi(key); err == nil && number >= 1 {
+		return number - 1
+	}
+
+	for i, name := range s.header {
+		if name == key {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// compareCSV метод сравнения двух CSV-записей по колонкам ключей (Keys или Columns) по очереди, каждая колонка
+// сравнивается с учетом опций типа сравнения. Без ключей записи сравниваются по всем колонкам
+func (s *Sorter) compareCSV(record1, record2 string) int {
+	fields1 := decodeCSV(record1)
+	fields2 := decodeCSV(record2)
+
+	for _, index := range s.csvIndexes(max(len(fields1), len(fields2))) {
+		result := s.compareKeys(s.trimKey(csvField(fields1, index)), s.trimKey(csvField(fields2, index)))
+		if result != 0 {
+			return result
+		}
+	}
+
+	return 0
+}
+
+// csvIndexes метод, возвращающий индексы колонок ключей CSV (Keys или Columns), без ключей - индексы всех count
+// колонок
+func (s *Sorter) csvIndexes(count int) []int {
+	var indexes []int
+
+	switch {
+	case len(s.options.Keys) > 0:
+		for _, key := range s.options.Keys {
+			indexes = append(indexes, s.columnIndex(key))
+		}
+	case len(s.options.Columns) > 0:
+		for _, column := range s.options.Columns {
+			indexes = append(indexes, column-1)
+		}
+	default:
+		for i := 0; i < count; i++ {
+			indexes = append(indexes, i)
+		}
+	}
+
+	return indexes
+}
+
+// csvField возвращает значение колонки записи, пустую строку, если колонки нет
+func csvField(fields []string, index int) string {
+	if index >= 0 && index < len(fields) {
+		return fields[index]
+	}
+
+	return ""
+}
+
+// jsonValue значение по JSON pointer с порядком типов для сравнения
+type jsonValue struct {
+	rank   int
+	number float64
+	text   string
+}
+
+// порядок типов JSON-значений при сравнении
+const (
+	jsonMissing = iota
+	jsonNull
+	jsonBool
+	jsonNumber
+	jsonString
+	jsonComposite
+)
+
+// resolvePointer возвращает значение по JSON pointer (RFC 6901) в разобранном JSON-документе и признак его наличия
+func resolvePointer(document any, pointer string) (any, bool) {
+	if pointer == "" {
+		return document, true
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, false
+	}
+
+	current := document
+
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+
+		switch node := current.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, false
+			}
+
+			current = value
+		case []any:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+// jsonKeys метод, возвращающий значения ключей (JSON pointer) строки JSON Lines, для некорректного JSON все значения
+// считаются отсутствующими
+func (s *Sorter) jsonKeys(line string) []jsonValue {
+	pointers := s.options.Keys
+	if len(pointers) == 0 {
+		pointers = []string{""}
+	}
+
+	values := make([]jsonValue, len(pointers))
+
+	var document any
+
+	decoder := json.NewDecoder(strings.NewReader(line))
+	decoder.UseNumber()
+
+	if decoder.Decode(&document) != nil {
+		return values
+	}
+
+	for i, pointer := range pointers {
+		value, ok := resolvePointer(document, pointer)
+		if !ok {
+			continue
+		}
+
+		switch typed := value.(type) {
+		case nil:
+			values[i] = jsonValue{rank: jsonNull}
+		case bool:
+			values[i] = jsonValue{rank: jsonBool, text: strconv.FormatBool(typed)}
+		case json.Number:
+			number, _ := typed.Float64()
+			values[i] = jsonValue{rank: jsonNumber, number: number, text: typed.String()}
+		case string:
+			values[i] = jsonValue{rank: jsonString, text: typed}
+		default:
+			text, _ := json.Marshal(typed)
+			values[i] = jsonValue{rank: jsonComposite, text: string(text)}
+		}
+	}
+
+	return values
+}
+
+// compareJSON метод сравнения двух строк JSON Lines по значениям ключей по очереди: отсутствующие значения идут
+// первыми, далее null, логические значения, числа (по значению), строки (с учетом опций типа сравнения), объекты и
+// массивы
+func (s *Sorter) compareJSON(line1, line2 string) int {
+	values1 := s.jsonKeys(line1)
+	values2 := s.jsonKeys(line2)
+
+	for i := range values1 {
+		value1, value2 := values1[i], values2[i]
+
+		if value1.rank != value2.rank {
+			return cmp.Compare(value1.rank, value2.rank)
+		}
+
+		var result int
+
+		switch value1.rank {
+		case jsonNumber:
+			result = cmp.Compare(value1.number, value2.number)
+		case jsonString:
+			result = s.compareKeys(s.trimKey(value1.text), s.trimKey(value2.text))
+		default:
+			result = cmp.Compare(value1.text, value2.text)
+		}
+
+		if result != 0 {
+			return result
+		}
+	}
+
+	return 0
+}
+
+// ReadLines метод, читающий записи из inputs в соответствии с форматом. Для CSV с заголовком заголовок первого
+// источника становится первой записью результата, заголовки остальных источников пропускаются
+func (s *Sorter) ReadLines(inputs ...io.Reader) ([]string, error) {
+	var lines []string
+
+	add := func(line string) {
+		lines = append(lines, line)
+	}
+
+	err := s.readRecords(inputs, add, add)
+	if err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// readRecords метод, читающий записи из inputs в соответствии с форматом и передающий заголовок CSV первого
+// источника в addHeader, а остальные записи - в addRecord
+func (s *Sorter) readRecords(inputs []io.Reader, addHeader, addRecord func(line string)) error {
+	for i, input := range inputs {
+		scanner := s.newScanner(input)
+
+		// заголовок первого источника сохраняется, заголовки остальных пропускаются
+		if s.hasHeader() && scanner.Scan() && i == 0 {
+			err := s.setHeader(scanner.Text())
+			if err != nil {
+				return err
+			}
+
+			addHeader(scanner.Text())
+		}
+
+		for scanner.Scan() {
+			addRecord(scanner.Text())
+		}
+
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,651 @@
+// Package sorter реализует сортировку, слияние и проверку отсортированности строк утилиты sort без привязки к флагам
+// командной строки и стандартным потокам ввода-вывода, что позволяет встраивать её в другие программы
+package sorter
+
+import (
+	"bufio"
+	"cmp"
+	"container/heap"
+	"errors"
+	"fmt"
+	"golang.org/x/text/collate"
+	"io"
+	"math/rand"
+	"slices"
+	"strings"
+	"wb-level-2/develop/dev03/utils"
+)
+
+var (
+	// ErrUnsorted ошибка слияния, возвращаемая, если один из источников данных оказался не отсортирован
+	ErrUnsorted = errors.New("input is not sorted")
+	// ErrDisorder ошибка проверки отсортированности данных
+	ErrDisorder = errors.New("disorder")
+)
+
+// SortOptions структура, определяющая опции сортировки
+type SortOptions struct {
+	// Columns номера колонок (начиная с 1), из которых составляется ключ сортировки, пустой слайс - вся строка
+	Columns []int
+	// Numeric сортировать по числовому значению
+	Numeric bool
+	// NumericSuffix сортировать по размерам в человекочитаемом формате (1K, 2Mi, 3GB)
+	NumericSuffix bool
+	// SI суффиксы размеров означают степени 1000, а не 1024. Порядок сортировки не меняется, так как размеры
+	// сравниваются сначала по суффиксу, а затем по значению
+	SI bool
+	// GeneralNumeric сортировать по числовому значению с плавающей точкой
+	GeneralNumeric bool
+	// TimeKey сортировать по дате и времени
+	TimeKey bool
+	// TimeLayout формат даты и времени для TimeKey в нотации Go (time.Layout), пустой - автоопределение RFC 3339,
+	// RFC 1123, Unix epoch
+	TimeLayout string
+	// TimeUnparsedFirst ключи, не разобранные как время, идут первыми (по умолчанию последними) независимо от Reverse
+	TimeUnparsedFirst bool
+	// Month сортировать по названию месяца
+	Month bool
+	// Version сортировать в натуральном порядке номеров версий
+	Version bool
+	// Random сортировать по случайному хэшу ключей
+	Random bool
+	// Shuffle случайно перемешать строки вместо сортировки
+	Shuffle bool
+	// Seed зерно для Random и Shuffle
+	Seed uint64
+	// Reverse сортировать в обратном порядке
+	Reverse bool
+	// Unique не выводить строки с повторяющимися ключами
+	Unique bool
+	// IgnoreLeadingBlanks игнорировать пробельные символы в начале ключа
+	IgnoreLeadingBlanks bool
+	// FoldCase не учитывать регистр
+	FoldCase bool
+	// Dictionary учитывать только пробельные символы, буквы и цифры
+	Dictionary bool
+	// IgnoreNonPrinting учитывать только печатаемые символы
+	IgnoreNonPrinting bool
+	// Collator правила сравнения строк локали, nil - побайтовое сравнение
+	Collator *collate.Collator
+	// ZeroTerminated записи разделяются нулевым байтом, а не переводом строки
+	ZeroTerminated bool
+	// Format формат входных данных, пустая строка - FormatText
+	Format Format
+	// Keys ключи сортировки структурированных данных: имена или номера колонок CSV, JSON pointer для JSON Lines
+	Keys []string
+	// NoHeader первая запись CSV не является заголовком
+	NoHeader bool
+	// Head выводить только первые Head записей отсортированных данных, 0 - все записи
+	Head int
+	// Tail выводить только последние Tail записей отсортированных данных, 0 - все записи
+	Tail int
+}
+
+// Sorter структура для сортировки, слияния и проверки отсортированности данных с заданными опциями
+type Sorter struct {
+	options SortOptions
+	header  []string
+}
+
+// New конструктор для создания объекта структуры Sorter
+func New(options SortOptions) *Sorter {
+	return &Sorter{options: options}
+}
+
+// DisorderError ошибка проверки отсортированности, содержащая первую строку, нарушающую сортировку
+type DisorderError struct {
+	Name string
+	Line int
+	Text string
+}
+
+// Error возвращает описание ошибки в формате GNU sort: файл:строка: disorder: строка
+func (de *DisorderError) Error() string {
+	return fmt.Sprintf("%s:%d: disorder: %s", de.Name, de.Line, de.Text)
+}
+
+// Unwrap позволяет сравнивать ошибку с ErrDisorder через errors.Is
+func (de *DisorderError) Unwrap() error {
+	return ErrDisorder
+}
+
+// namedReader reader с именем для диагностических сообщений
+type namedReader struct {
+	io.Reader
+	name string
+}
+
+// Name возвращает имя reader'а
+func (nr namedReader) Name() string {
+	return nr.name
+}
+
+// NamedReader возвращает reader с именем name, которое используется в диагностических сообщениях Merge и Check.
+// Для *os.File именем по умолчанию служит путь до файла
+func NamedReader(name string, reader io.Reader) io.Reader {
+	return namedReader{Reader: reader, name: name}
+}
+
+// inputName возвращает имя источника данных для диагностических сообщений, "-" - если у источника нет имени
+func inputName(reader io.Reader) string {
+	if named, ok := reader.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+
+	return "-"
+}
+
+// delimiter метод, возвращающий разделитель записей
+func (s *Sorter) delimiter() byte {
+	if s.options.ZeroTerminated {
+		return 0
+	}
+
+	return '\n'
+}
+
+// Compare метод сравнения двух строк с учетом всех опций сортировки, в том числе направления, возвращает -1, 0 или 1
+// аналогично cmp.Compare. Строки с равными ключами, как в GNU sort, сравниваются целиком побайтово, кроме опции
+// Unique, при которой такие строки считаются равными
+func (s *Sorter) Compare(line1, line2 string) int {
+	result := s.compare(line1, line2)
+	if result == 0 && !s.options.Unique {
+		result = cmp.Compare(line1, line2)
+	}
+
+	if s.options.Reverse {
+		return -result
+	}
+
+	return result
+}
+
+// compareKeysOrder метод сравнения двух строк только по ключам с учетом направления сортировки, без сравнения строк
+// целиком при равных ключах
+func (s *Sorter) compareKeysOrder(line1, line2 string) int {
+	if s.options.Reverse {
+		return -s.compare(line1, line2)
+	}
+
+	return s.compare(line1, line2)
+}
+
+// compare метод сравнения двух строк с учетом формата данных, опций ключа и типа сравнения, возвращает -1, 0 или 1
+// аналогично cmp.Compare. Направление сортировки не учитывается
+func (s *Sorter) compare(line1, line2 string) int {
+	switch s.options.Format {
+	case FormatCSV:
+		return s.compareCSV(line1, line2)
+	case FormatJSONL:
+		return s.compareJSON(line1, line2)
+	default:
+		return s.compareKeys(s.key(line1), s.key(line2))
+	}
+}
+
+// compareKeys метод сравнения двух уже выделенных ключей сортировки с учетом опций типа сравнения
+func (s *Sorter) compareKeys(line1, line2 string) int {
+	// учитывание опции Random
+	if s.options.Random {
+		hash1 := utils.HashKey(s.options.Seed, s.textKey(line1))
+		hash2 := utils.HashKey(s.options.Seed, s.textKey(line2))
+
+		if hash1 != hash2 {
+			return cmp.Compare(hash1, hash2)
+		}
+
+		return s.compareText(line1, line2)
+	}
+
+	// учитывание опции GeneralNumeric, строки, не начинающиеся с числа, идут первыми, далее NaN, затем числа по
+	// возрастанию
+	if s.options.GeneralNumeric {
+		num1, err1 := utils.ParseGeneralNumericValue(line1)
+		num2, err2 := utils.ParseGeneralNumericValue(line2)
+
+		switch {
+		case err1 != nil && err2 != nil:
+			return s.compareText(line1, line2)
+		case err1 != nil:
+			return -1
+		case err2 != nil:
+			return 1
+		}
+
+		return cmp.Compare(num1, num2)
+	}
+
+	// учитывание опции NumericSuffix, размеры сравниваются сначала по порядку суффикса, затем по значению
+	if s.options.NumericSuffix {
+		result, err := utils.CompareHumanSizes(line1, line2, s.options.SI)
+		if err != nil {
+			return s.compareText(line1, line2)
+		}
+
+		return result
+	}
+
+	// учитывание опции Numeric
+	if s.options.Numeric {
+		num1, err1 := utils.ParseNumericValue(line1, false)
+		num2, err2 := utils.ParseNumericValue(line2, false)
+
+		if err1 != nil || err2 != nil {
+			return s.compareText(line1, line2)
+		}
+
+		return cmp.Compare(num1, num2)
+	}
+
+	// учитывание опции TimeKey
+	if s.options.TimeKey {
+		return s.compareTimes(line1, line2)
+	}
+
+	// учитывание опции Month
+	if s.options.Month {
+		indMonth1, err1 := utils.ParseMonth(line1)
+		indMonth2, err2 := utils.ParseMonth(line2)
+
+		if err1 != nil || err2 != nil {
+			return s.compareText(line1, line2)
+		}
+
+		return cmp.Compare(indMonth1, indMonth2)
+	}
+
+	// учитывание опции Version
+	if s.options.Version {
+		if result := utils.CompareVersions(line1, line2); result != 0 {
+			return result
+		}
+	}
+
+	return s.compareText(line1, line2)
+}
+
+// compareTimes метод хронологического сравнения двух ключей, ключи, не разобранные как время, сравниваются как
+// текст и идут в конце (опция TimeUnparsedFirst - в начале) с учетом направления сортировки
+func (s *Sorter) compareTimes(line1, line2 string) int {
+	time1, err1 := utils.ParseTime(line1, s.options.TimeLayout)
+	time2, err2 := utils.ParseTime(line2, s.options.TimeLayout)
+
+	// положение неразобранного ключа относительно разобранного, не зависящее от опции Reverse
+	unparsed := 1
+	if s.options.TimeUnparsedFirst != s.options.Reverse {
+		unparsed = -1
+	}
+
+	switch {
+	case err1 != nil && err2 != nil:
+		return s.compareText(line1, line2)
+	case err1 != nil:
+		return unparsed
+	case err2 != nil:
+		return -unparsed
+	}
+
+	return time1.Compare(time2)
+}
+
+// key метод, выделяющий из строки ключ сортировки с учетом опций Columns, IgnoreLeadingBlanks
+func (s *Sorter) key(line string) string {
+	// учитывание опции Columns
+	if len(s.options.Columns) > 0 {
+		fields := strings.Fields(line)
+
+		var builder strings.Builder
+
+		for _, fieldNumber := range s.options.Columns {
+			if fieldNumber >= 1 && fieldNumber <= len(fields) {
+				// учитывание опции TimeKey, колонки даты и времени разделяются пробелом
+				if s.options.TimeKey && builder.Len() > 0 {
+					builder.WriteByte(' ')
+				}
+
+				builder.WriteString(fields[fieldNumber-1])
+			}
+		}
+
+		line = builder.String()
+	}
+
+	return s.trimKey(line)
+}
+
+// trimKey метод, убирающий пробельные символы в начале ключа с учетом опции IgnoreLeadingBlanks
+func (s *Sorter) trimKey(line string) string {
+	if s.options.IgnoreLeadingBlanks {
+		return utils.TrimLeadingSpaces(line)
+	}
+
+	return line
+}
+
+// textKey метод, преобразующий ключ для текстового сравнения с учетом опций Dictionary, IgnoreNonPrinting, FoldCase
+func (s *Sorter) textKey(line string) string {
+	// учитывание опции Dictionary
+	if s.options.Dictionary {
+		line = utils.DictionaryOrder(line)
+	}
+
+	// учитывание опции IgnoreNonPrinting
+	if s.options.IgnoreNonPrinting {
+		line = utils.IgnoreNonPrinting(line)
+	}
+
+	// учитывание опции FoldCase
+	if s.options.FoldCase {
+		line = strings.ToUpper(line)
+	}
+
+	return line
+}
+
+// compareText метод текстового сравнения двух строк с учетом опций Dictionary, IgnoreNonPrinting, FoldCase и правил
+// сравнения локали
+func (s *Sorter) compareText(line1, line2 string) int {
+	line1 = s.textKey(line1)
+	line2 = s.textKey(line2)
+
+	// учитывание опции Collator
+	if s.options.Collator != nil {
+		return s.options.Collator.CompareString(line1, line2)
+	}
+
+	return cmp.Compare(line1, line2)
+}
+
+// SortLines метод, возвращающий отсортированную копию слайса строк. Для CSV с заголовком первая строка считается
+// заголовком и остается первой
+func (s *Sorter) SortLines(lines []string) []string {
+	// создание копии слайса данных для сортировки
+	result := make([]string, len(lines), cap(lines))
+
+	copy(result, lines)
+
+	// учитывание заголовка CSV, неизвестные колонки ключей при этом считаются пустыми
+	if s.hasHeader() && len(result) > 0 {
+		_ = s.setHeader(result[0])
+
+		return append(result[:1], s.sortRecords(result[1:])...)
+	}
+
+	return s.sortRecords(result)
+}
+
+// sortRecords метод, сортирующий слайс записей на месте с учетом опций Shuffle, Unique, Reverse, Head, Tail,
+// возвращает отсортированный слайс
+func (s *Sorter) sortRecords(result []string) []string {
+	// учитывание опций Head, Tail
+	if s.partial() {
+		return s.topRecords(result)
+	}
+
+	// учитывание опции Shuffle, вместо сортировки строки случайно перемешиваются
+	if s.options.Shuffle {
+		random := rand.New(rand.NewSource(int64(s.options.Seed)))
+		random.Shuffle(len(result), func(i, j int) {
+			result[i], result[j] = result[j], result[i]
+		})
+
+		return limit(result, s.options.Head, s.options.Tail)
+	}
+
+	// учитывание опции Unique, сортировка производится устойчиво с учетом направления сортировки, после чего из
+	// каждой последовательности строк с равными ключами остается только первая
+	if s.options.Unique {
+		slices.SortStableFunc(result, s.Compare)
+
+		return slices.CompactFunc(result, func(line1, line2 string) bool {
+			return s.compare(line1, line2) == 0
+		})
+	}
+
+	// сортировка данных
+	slices.SortFunc(result, s.compare)
+
+	// учитывание опции Reverse
+	if s.options.Reverse {
+		slices.Reverse(result)
+	}
+
+	return result
+}
+
+// limit возвращает первые head или последние tail строк слайса, если они заданы
+func limit(lines []string, head, tail int) []string {
+	switch {
+	case head > 0 && head < len(lines):
+		return lines[:head]
+	case tail > 0 && tail < len(lines):
+		return lines[len(lines)-tail:]
+	default:
+		return lines
+	}
+}
+
+// Sort метод, читающий все записи из inputs, сортирующий их и записывающий результат в writer. При опциях Head, Tail
+// в памяти хранятся только отбираемые записи
+func (s *Sorter) Sort(writer io.Writer, inputs ...io.Reader) error {
+	lines, err := s.Top(inputs...)
+	if err != nil {
+		return err
+	}
+
+	bufferedWriter := bufio.NewWriter(writer)
+
+	err = utils.WriteRecords(bufferedWriter, s.delimiter(), lines...)
+	if err != nil {
+		return err
+	}
+
+	return bufferedWriter.Flush()
+}
+
+// IsSorted метод, возвращающий -1 в случае, если строки отсортированы в соответствии с опциями, иначе индекс первой
+// строки, нарушающей сортировку. Проверка выполняется за один проход сравнением соседних строк, при опции Unique
+// равные соседние строки также считаются нарушением
+func (s *Sorter) IsSorted(lines []string) int {
+	for i := 1; i < len(lines); i++ {
+		if s.inOrder(lines[i-1], lines[i]) {
+			continue
+		}
+
+		return i
+	}
+
+	return -1
+}
+
+// inOrder метод, проверяющий, что строка line может следовать за строкой prev в отсортированных данных
+func (s *Sorter) inOrder(prev, line string) bool {
+	result := s.compareKeysOrder(prev, line)
+
+	// учитывание опции Unique
+	return result < 0 || (result == 0 && !s.options.Unique)
+}
+
+// Check метод для проверки отсортированности данных из input за один проход без загрузки их в память, возвращает
+// *DisorderError с первой строкой, нарушающей сортировку, или ошибку чтения
+func (s *Sorter) Check(input io.Reader) error {
+	var prev string
+
+	scanner := s.newScanner(input)
+	first := 1
+
+	// учитывание заголовка CSV, он в проверке не участвует
+	if s.hasHeader() && scanner.Scan() {
+		err := s.setHeader(scanner.Text())
+		if err != nil {
+			return err
+		}
+
+		first = 2
+	}
+
+	for lineNumber := first; scanner.Scan(); lineNumber++ {
+		line := scanner.Text()
+
+		if lineNumber > first && !s.inOrder(prev, line) {
+			return &DisorderError{Name: inputName(input), Line: lineNumber, Text: line}
+		}
+
+		prev = line
+	}
+
+	return scanner.Err()
+}
+
+// mergeSource структура источника данных для слияния: отсортированный reader, читаемый построчно, и его текущая
+// строка
+type mergeSource struct {
+	name       string
+	scanner    recordScanner
+	line       string
+	lineNumber int
+	index      int
+	// hasLine признак того, что строка line уже прочитана, первая строка данных не сравнивается с предыдущей
+	hasLine bool
+}
+
+// mergeHeap куча источников данных для k-way слияния, на вершине источник с наименьшей текущей строкой
+type mergeHeap struct {
+	sources []*mergeSource
+	compare func(line1, line2 string) int
+}
+
+func (mh *mergeHeap) Len() int { return len(mh.sources) }
+
+func (mh *mergeHeap) Less(i, j int) bool {
+	result := mh.compare(mh.sources[i].line, mh.sources[j].line)
+	if result == 0 {
+		// при равенстве строк первой выводится строка из источника, переданного раньше
+		return mh.sources[i].index < mh.sources[j].index
+	}
+
+	return result < 0
+}
+
+func (mh *mergeHeap) Swap(i, j int) { mh.sources[i], mh.sources[j] = mh.sources[j], mh.sources[i] }
+
+func (mh *mergeHeap) Push(x any) { mh.sources = append(mh.sources, x.(*mergeSource)) }
+
+func (mh *mergeHeap) Pop() any {
+	old := mh.sources
+	n := len(old)
+	source := old[n-1]
+	mh.sources = old[:n-1]
+
+	return source
+}
+
+// advance метод для чтения следующей строки источника, возвращает false, если источник закончился, и ошибку, если
+// источник оказался не отсортирован
+func (ms *mergeSource) advance(compare func(line1, line2 string) int) (bool, error) {
+	if !ms.scanner.Scan() {
+		return false, ms.scanner.Err()
+	}
+
+	line := ms.scanner.Text()
+	ms.lineNumber++
+
+	if ms.hasLine && compare(ms.line, line) > 0 {
+		return false, fmt.Errorf("%s:%d: %w: %s", ms.name, ms.lineNumber, ErrUnsorted, line)
+	}
+
+	ms.line = line
+	ms.hasLine = true
+
+	return true, nil
+}
+
+// Merge метод для слияния уже отсортированных inputs в writer без загрузки их в память целиком, возвращает ошибку,
+// оборачивающую ErrUnsorted, если один из источников оказался не отсортирован. Строки, слитые до обнаружения ошибки,
+// записываются в writer
+func (s *Sorter) Merge(writer io.Writer, inputs ...io.Reader) error {
+	bufferedWriter := bufio.NewWriter(writer)
+
+	err := s.merge(bufferedWriter, inputs)
+
+	flushErr := bufferedWriter.Flush()
+	if err != nil {
+		return err
+	}
+
+	return flushErr
+}
+
+// merge метод k-way слияния inputs в writer
+func (s *Sorter) merge(writer io.Writer, inputs []io.Reader) error {
+	mh := &mergeHeap{compare: s.compareKeysOrder}
+
+	// чтение первой строки каждого источника
+	for i, input := range inputs {
+		source := &mergeSource{
+			name:    inputName(input),
+			scanner: s.newScanner(input),
+			index:   i,
+		}
+
+		// учитывание заголовка CSV, выводится только заголовок первого источника
+		if s.hasHeader() && source.scanner.Scan() {
+			source.lineNumber++
+
+			if i == 0 {
+				err := s.setHeader(source.scanner.Text())
+				if err != nil {
+					return err
+				}
+
+				err = utils.WriteRecords(writer, s.delimiter(), source.scanner.Text())
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		ok, err := source.advance(s.compareKeysOrder)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			mh.sources = append(mh.sources, source)
+		}
+	}
+
+	heap.Init(mh)
+
+	var prev string
+	written := false
+
+	// извлечение наименьшей строки, её запись и чтение следующей строки того же источника
+	for mh.Len() > 0 {
+		source := mh.sources[0]
+
+		// учитывание опции Unique, строки сравниваются по ключу
+		if !s.options.Unique || !written || s.compare(prev, source.line) != 0 {
+			err := utils.WriteRecords(writer, s.delimiter(), source.line)
+			if err != nil {
+				return err
+			}
+
+			prev = source.line
+			written = true
+		}
+
+		ok, err := source.advance(s.compareKeysOrder)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			heap.Fix(mh, 0)
+		} else {
+			heap.Pop(mh)
+		}
+	}
+
+	return nil
+}
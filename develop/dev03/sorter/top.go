@@ -0,0 +1,205 @@
+package sorter
+
+import (
+	"container/heap"
+	"io"
+	"slices"
+)
+
+// topRecord запись, отобранная в частичной сортировке, и её порядковый номер во входных данных
+type topRecord struct {
+	line  string
+	index int
+}
+
+// topHeap ограниченная куча записей для частичной сортировки (опции Head, Tail), на вершине худшая из отобранных
+// записей: наибольшая для Head и наименьшая для Tail
+type topHeap struct {
+	records []topRecord
+	less    func(record1, record2 topRecord) bool
+}
+
+func (th *topHeap) Len() int { return len(th.records) }
+
+func (th *topHeap) Less(i, j int) bool { return th.less(th.records[i], th.records[j]) }
+
+func (th *topHeap) Swap(i, j int) { th.records[i], th.records[j] = th.records[j], th.records[i] }
+
+func (th *topHeap) Push(x any) { th.records = append(th.records, x.(topRecord)) }
+
+func (th *topHeap) Pop() any {
+	old := th.records
+	n := len(old)
+	record := old[n-1]
+	th.records = old[:n-1]
+
+	return record
+}
+
+// topSelector структура для отбора первых (Head) или последних (Tail) limit записей отсортированных данных за один
+// проход, в памяти хранится не более limit записей
+type topSelector struct {
+	sorter *Sorter
+	heap   *topHeap
+	limit  int
+	tail   bool
+	count  int
+	// keys множество ключей отобранных записей для опции Unique, упорядоченное сравнением без учета направления
+	keys []string
+}
+
+// newTopSelector метод, возвращающий topSelector в соответствии с опциями Head, Tail
+func (s *Sorter) newTopSelector() *topSelector {
+	ts := &topSelector{sorter: s, limit: s.options.Head}
+
+	if s.options.Tail > 0 {
+		ts.limit = s.options.Tail
+		ts.tail = true
+	}
+
+	ts.heap = &topHeap{records: make([]topRecord, 0, ts.limit), less: ts.before}
+
+	// для Head на вершине кучи наибольшая запись, поэтому порядок кучи обратный
+	if !ts.tail {
+		ts.heap.less = func(record1, record2 topRecord) bool {
+			return ts.before(record2, record1)
+		}
+	}
+
+	return ts
+}
+
+// partial метод, проверяющий, задана ли частичная сортировка (опции Head, Tail). При опции Shuffle частичная
+// сортировка не используется, так как перемешивание не задает порядок сравнением
+func (s *Sorter) partial() bool {
+	return (s.options.Head > 0 || s.options.Tail > 0) && !s.options.Shuffle
+}
+
+// before метод, проверяющий, что запись record1 идет раньше record2 в отсортированных данных. Записи с равными
+// ключами сравниваются целиком (Compare), одинаковые записи упорядочиваются по порядку во входных данных. При опции
+// Unique записи с равными ключами считаются равными
+func (ts *topSelector) before(record1, record2 topRecord) bool {
+	result := ts.sorter.Compare(record1.line, record2.line)
+	if result == 0 && !ts.sorter.options.Unique {
+		return record1.index < record2.index
+	}
+
+	return result < 0
+}
+
+// add метод, добавляющий запись в кандидаты, если она входит в limit первых (последних) записей среди уже
+// просмотренных, при этом худший кандидат вытесняется
+func (ts *topSelector) add(line string) {
+	record := topRecord{line: line, index: ts.count}
+	ts.count++
+
+	// учитывание опции Unique, из записей с равными ключами остается первая во входных данных. Если равная запись
+	// уже была вытеснена, новая запись также не пройдет отбор
+	if ts.sorter.options.Unique {
+		if _, found := ts.findKey(line); found {
+			return
+		}
+	}
+
+	if ts.heap.Len() < ts.limit {
+		heap.Push(ts.heap, record)
+		ts.insertKey(line)
+
+		return
+	}
+
+	// новая запись заменяет вершину кучи, если она лучше худшего из кандидатов
+	if ts.heap.less(ts.heap.records[0], record) {
+		ts.removeKey(ts.heap.records[0].line)
+		ts.insertKey(line)
+
+		ts.heap.records[0] = record
+		heap.Fix(ts.heap, 0)
+	}
+}
+
+// findKey метод, возвращающий позицию ключа строки в множестве ключей и признак его наличия
+func (ts *topSelector) findKey(line string) (int, bool) {
+	return slices.BinarySearchFunc(ts.keys, line, ts.sorter.compare)
+}
+
+// insertKey метод, добавляющий ключ строки в множество ключей при опции Unique
+func (ts *topSelector) insertKey(line string) {
+	if !ts.sorter.options.Unique {
+		return
+	}
+
+	position, _ := ts.findKey(line)
+	ts.keys = slices.Insert(ts.keys, position, line)
+}
+
+// removeKey метод, удаляющий ключ строки из множества ключей при опции Unique
+func (ts *topSelector) removeKey(line string) {
+	if !ts.sorter.options.Unique {
+		return
+	}
+
+	if position, found := ts.findKey(line); found {
+		ts.keys = slices.Delete(ts.keys, position, position+1)
+	}
+}
+
+// lines метод, возвращающий отобранные записи в отсортированном порядке
+func (ts *topSelector) lines() []string {
+	records := slices.Clone(ts.heap.records)
+
+	slices.SortFunc(records, func(record1, record2 topRecord) int {
+		if ts.before(record1, record2) {
+			return -1
+		}
+
+		return 1
+	})
+
+	result := make([]string, len(records))
+	for i, record := range records {
+		result[i] = record.line
+	}
+
+	return result
+}
+
+// topRecords метод, возвращающий первые (Head) или последние (Tail) записи отсортированных records
+func (s *Sorter) topRecords(records []string) []string {
+	ts := s.newTopSelector()
+
+	for _, record := range records {
+		ts.add(record)
+	}
+
+	return ts.lines()
+}
+
+// Top метод, читающий записи из inputs и возвращающий только первые (опция Head) или последние (опция Tail) записи
+// отсортированных данных, не загружая данные в память целиком: хранится не более Head (Tail) записей. Записи с
+// равными ключами, как в GNU sort | head, упорядочиваются сравнением записей целиком. Для CSV с заголовком заголовок
+// остается первой строкой результата и в число записей не входит
+func (s *Sorter) Top(inputs ...io.Reader) ([]string, error) {
+	// без частичной сортировки данные сортируются целиком
+	if !s.partial() {
+		lines, err := s.ReadLines(inputs...)
+		if err != nil {
+			return nil, err
+		}
+
+		return s.SortLines(lines), nil
+	}
+
+	var header []string
+
+	ts := s.newTopSelector()
+
+	err := s.readRecords(inputs, func(line string) {
+		header = append(header, line)
+	}, ts.add)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(header, ts.lines()...), nil
+}
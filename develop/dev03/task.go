@@ -1,16 +1,20 @@
 package main
 
 import (
-	"cmp"
+	"bufio"
+	crand "crypto/rand"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"golang.org/x/text/collate"
+	"io"
 	"os"
 	"slices"
 	s "sort"
 	"strconv"
 	"strings"
+	"wb-level-2/develop/dev03/sorter"
 	"wb-level-2/develop/dev03/utils"
 )
 
@@ -39,7 +43,12 @@ import (
 Программа должна проходить все тесты. Код должен проходить проверки go vet и golint.
 */
 
-var errParse = errors.New("parse error")
+var (
+	errParse        = errors.New("parse error")
+	errExtraOperand = errors.New("extra operand not allowed with -c")
+	errHeadTail     = errors.New("--head and --tail require a non-negative number and cannot be combined")
+	errTimeUnparsed = errors.New("--time-unparsed must be first or last")
+)
 
 // IntSlice тип для задания слайса по списку точек и отрезков. Точка - целое число, отрезок - множество целых чисел,
 // находящееся между крайними точками отрезка, включая сами крайние точки. Перечисление элементов (точек и отрезков)
@@ -100,14 +109,38 @@ func (is *IntSlice) UnmarshalText(b []byte) error {
 
 // SortFlags структура, определяющюая опции утилиты Sort
 type SortFlags struct {
-	columns       IntSlice
-	numeric       bool
-	reverse       bool
-	unique        bool
-	month         bool
-	ignoreSpaces  bool
-	checkSorted   bool
-	numericSuffix bool
+	columns        IntSlice
+	numeric        bool
+	reverse        bool
+	unique         bool
+	month          bool
+	ignoreSpaces   bool
+	checkSorted    bool
+	numericSuffix  bool
+	generalNumeric bool
+	version        bool
+	merge          bool
+	checkSilent    bool
+	locale         string
+	foldCase       bool
+	dictionary     bool
+	ignoreNonPrint bool
+	output         string
+	zeroTerminated bool
+	random         bool
+	randomSource   string
+	seed           int64
+	shuffle        bool
+	format         string
+	keys           []string
+	noHeader       bool
+	si             bool
+	head           int
+	tail           int
+	debug          bool
+	timeKey        bool
+	timeLayout     string
+	timeUnparsed   string
 }
 
 // Parse метод для распарсивания и сохранения значений флагов опций в поля структуры SortFlags
@@ -120,10 +153,66 @@ func (sf *SortFlags) Parse() {
 	flag.BoolVar(&sf.ignoreSpaces, "b", false, "Ignore trailing spaces")
 	flag.BoolVar(&sf.checkSorted, "c", false, "Check if the data is sorted")
 	flag.BoolVar(&sf.numericSuffix, "h", false, "Sort by numeric value with suffixes")
+	flag.BoolVar(&sf.generalNumeric, "g", false, "Sort by general numeric value")
+	flag.BoolVar(&sf.version, "V", false, "Natural sort of (version) numbers within text")
+	flag.BoolVar(&sf.merge, "m", false, "Merge already sorted files; do not sort")
+	flag.BoolVar(&sf.checkSilent, "C", false, "Check if the data is sorted, do not report the first disorder")
+	flag.StringVar(&sf.locale, "locale", "", "Compare strings using collation rules of the locale (default $LC_COLLATE)")
+	flag.BoolVar(&sf.foldCase, "f", false, "Fold lower case to upper case characters")
+	flag.BoolVar(&sf.dictionary, "d", false, "Consider only blanks and alphanumeric characters")
+	flag.BoolVar(&sf.ignoreNonPrint, "i", false, "Consider only printable characters")
+	flag.StringVar(&sf.output, "o", "", "Write result to file instead of standard output")
+	flag.BoolVar(&sf.zeroTerminated, "z", false, "Line delimiter is NUL, not newline")
+	flag.BoolVar(&sf.random, "R", false, "Sort by random hash of keys")
+	flag.StringVar(&sf.randomSource, "random-source", "", "Get random bytes from file")
+	flag.Int64Var(&sf.seed, "seed", 0, "Seed for -R and --shuffle, 0 means a random seed")
+	flag.BoolVar(&sf.shuffle, "shuffle", false, "Output lines in random order")
+	flag.StringVar(&sf.format, "format", "", "Input format: text, csv or jsonl (default text)")
+	flag.Func("key", "Keys: CSV column names or numbers, JSON pointers for jsonl", func(value string) error {
+		sf.keys = append(sf.keys, strings.Split(value, ",")...)
+		return nil
+	})
+	flag.BoolVar(&sf.noHeader, "no-header", false, "Do not treat the first CSV record as a header")
+	flag.BoolVar(&sf.si, "si", false, "Use powers of 1000 instead of 1024 for -h suffixes")
+	flag.IntVar(&sf.head, "head", 0, "Output only the first N lines of the sorted data")
+	flag.IntVar(&sf.tail, "tail", 0, "Output only the last N lines of the sorted data")
+	flag.BoolVar(&sf.timeKey, "time-key", false, "Sort by date and time")
+	flag.StringVar(&sf.timeLayout, "time-layout", "", "Go or strftime time layout (default RFC3339, RFC1123 or epoch)")
+	flag.StringVar(&sf.timeUnparsed, "time-unparsed", "", "Place keys that are not a time first or last (default last)")
+	flag.BoolVar(&sf.debug, "debug", false, "Annotate the part of the line used to sort, warn about parse fallbacks")
 
 	flag.Parse()
 }
 
+// delimiter метод, возвращающий разделитель записей с учетом опции -z
+func (sf *SortFlags) delimiter() byte {
+	if sf.zeroTerminated {
+		return 0
+	}
+
+	return '\n'
+}
+
+// randomSeed метод, возвращающий зерно для опций -R, --shuffle: из файла опции --random-source, значение опции --seed
+// или случайное, если ни одна из опций не задана
+func (sf *SortFlags) randomSeed() (uint64, error) {
+	switch {
+	case sf.randomSource != "":
+		randomSource, err := os.Open(sf.randomSource)
+		if err != nil {
+			return 0, err
+		}
+
+		defer randomSource.Close()
+
+		return utils.ReadSeed(randomSource)
+	case sf.seed != 0:
+		return uint64(sf.seed), nil
+	default:
+		return utils.ReadSeed(crand.Reader)
+	}
+}
+
 // SortArgs структура, определяющая неименованные аргументы запуска утилиты Sort
 type SortArgs struct {
 	inputFiles []*os.File
@@ -157,9 +246,12 @@ func (sa *SortArgs) Parse() error {
 
 // SortClient структура для управления утилитой Sort
 type SortClient struct {
-	flags SortFlags
-	args  SortArgs
-	data  []string
+	flags      SortFlags
+	args       SortArgs
+	data       []string
+	collator   *collate.Collator
+	seed       uint64
+	timeLayout string
 }
 
 // NewSortClient конструктор для создания объекта структуры SortClient
@@ -174,137 +266,236 @@ func NewSortClient() (*SortClient, error) {
 		return nil, err
 	}
 
-	// чтение и сохранение данных для сортировки в поле data структуры SortClient, закрытие reader'ов
-	for _, inputFile := range sc.args.inputFiles {
-		partData, err := utils.ReadData(inputFile)
+	// учитывание опции --locale, если она не задана - локаль берется из переменных окружения
+	locale := sc.flags.locale
+	if locale == "" {
+		locale = utils.LocaleFromEnv()
+	}
+
+	sc.collator, err = utils.NewCollator(locale)
+	if err != nil {
+		return nil, err
+	}
+
+	// проверка опции --format
+	_, err = sorter.ParseFormat(sc.flags.format)
+	if err != nil {
+		return nil, err
+	}
+
+	// учитывание опций -R, --shuffle, --random-source, --seed
+	if sc.flags.random || sc.flags.shuffle {
+		sc.seed, err = sc.flags.randomSeed()
 		if err != nil {
-			_ = inputFile.Close()
 			return nil, err
 		}
+	}
 
-		err = inputFile.Close()
+	// учитывание опций --time-layout, --time-unparsed, формат strftime переводится в нотацию Go
+	if strings.Contains(sc.flags.timeLayout, "%") {
+		sc.timeLayout, err = utils.StrftimeLayout(sc.flags.timeLayout)
 		if err != nil {
 			return nil, err
 		}
+	} else {
+		sc.timeLayout = sc.flags.timeLayout
+	}
 
-		sc.data = append(sc.data, partData...)
+	if !slices.Contains([]string{"", "first", "last"}, sc.flags.timeUnparsed) {
+		return nil, errTimeUnparsed
 	}
 
-	return sc, nil
-}
+	// проверка опций --head, --tail
+	if sc.flags.head < 0 || sc.flags.tail < 0 || (sc.flags.head > 0 && sc.flags.tail > 0) {
+		return nil, errHeadTail
+	}
 
-// Sort метод для функционирования утилиты, который возвращает отсортированные данные, исходя из установленных опций
-// при запуске утилиты, в случае отсутствия ошибок
-func (sc *SortClient) Sort() []string {
-	// создание копии слайса данных для сортировки, переданных в утилиту
-	result := make([]string, len(sc.data), cap(sc.data))
+	// при опциях -m, -c, -C данные не загружаются в память целиком, а читаются построчно во время слияния или
+	// проверки, при опциях --head, --tail в памяти хранятся только отбираемые строки
+	if sc.flags.merge || sc.flags.checkSorted || sc.flags.checkSilent || sc.partial() {
+		return sc, nil
+	}
 
-	copy(result, sc.data)
+	// чтение и сохранение данных для сортировки в поле data структуры SortClient с учетом формата данных, закрытие
+	// reader'ов
+	defer sc.closeInputs()
 
-	// сортировка копии данных
-	slices.SortFunc(result, func(line1, line2 string) int {
-		// учитывание опции -k
-		if len(sc.flags.columns) > 0 {
-			fields1 := strings.Fields(line1)
-			fields2 := strings.Fields(line2)
+	sc.data, err = sc.sorter().ReadLines(sc.inputs()...)
+	if err != nil {
+		return nil, err
+	}
 
-			var builder1 strings.Builder
-			var builder2 strings.Builder
+	return sc, nil
+}
 
-			for _, fieldNumber := range sc.flags.columns {
-				if fieldNumber >= 1 && fieldNumber <= len(fields1) {
-					builder1.WriteString(fields1[fieldNumber-1])
-				}
+// sorter метод, возвращающий объект sorter.Sorter с опциями сортировки, соответствующими флагам утилиты
+func (sc *SortClient) sorter() *sorter.Sorter {
+	return sorter.New(sorter.SortOptions{
+		Columns:             sc.flags.columns,
+		Numeric:             sc.flags.numeric,
+		NumericSuffix:       sc.flags.numericSuffix,
+		SI:                  sc.flags.si,
+		GeneralNumeric:      sc.flags.generalNumeric,
+		TimeKey:             sc.flags.timeKey,
+		TimeLayout:          sc.timeLayout,
+		TimeUnparsedFirst:   sc.flags.timeUnparsed == "first",
+		Month:               sc.flags.month,
+		Version:             sc.flags.version,
+		Random:              sc.flags.random,
+		Shuffle:             sc.flags.shuffle,
+		Seed:                sc.seed,
+		Reverse:             sc.flags.reverse,
+		Unique:              sc.flags.unique,
+		IgnoreLeadingBlanks: sc.flags.ignoreSpaces,
+		FoldCase:            sc.flags.foldCase,
+		Dictionary:          sc.flags.dictionary,
+		IgnoreNonPrinting:   sc.flags.ignoreNonPrint,
+		Collator:            sc.collator,
+		ZeroTerminated:      sc.flags.zeroTerminated,
+		Format:              sorter.Format(sc.flags.format),
+		Keys:                sc.flags.keys,
+		NoHeader:            sc.flags.noHeader,
+		Head:                sc.flags.head,
+		Tail:                sc.flags.tail,
+	})
+}
 
-				if fieldNumber >= 1 && fieldNumber <= len(fields2) {
-					builder2.WriteString(fields2[fieldNumber-1])
-				}
-			}
+// partial метод, проверяющий, задан ли вывод только части отсортированных данных (опции --head, --tail)
+func (sc *SortClient) partial() bool {
+	return sc.flags.head > 0 || sc.flags.tail > 0
+}
 
-			line1 = builder1.String()
-			line2 = builder2.String()
-		}
+// inputs метод, возвращающий входные файлы как источники данных для sorter.Sorter, стандартный ввод в
+// диагностических сообщениях называется "-"
+func (sc *SortClient) inputs() []io.Reader {
+	inputs := make([]io.Reader, 0, len(sc.args.inputFiles))
 
-		// учитывание опции -b
-		if sc.flags.ignoreSpaces {
-			line1 = utils.TrimLeadingSpaces(line1)
-			line2 = utils.TrimLeadingSpaces(line2)
+	for _, inputFile := range sc.args.inputFiles {
+		if inputFile == os.Stdin {
+			inputs = append(inputs, sorter.NamedReader("-", inputFile))
+		} else {
+			inputs = append(inputs, inputFile)
 		}
+	}
 
-		// учитывание опций -n, -h
-		if sc.flags.numeric || sc.flags.numericSuffix {
-			num1, err1 := utils.ParseNumericValue(line1, sc.flags.numericSuffix)
-			num2, err2 := utils.ParseNumericValue(line2, sc.flags.numericSuffix)
-
-			if err1 != nil || err2 != nil {
-				return cmp.Compare(line1, line2)
-			}
+	return inputs
+}
 
-			return cmp.Compare(num1, num2)
-		}
+// closeInputs метод, закрывающий входные файлы
+func (sc *SortClient) closeInputs() {
+	for _, inputFile := range sc.args.inputFiles {
+		_ = inputFile.Close()
+	}
+}
 
-		// учитывание опции -M
-		if sc.flags.month {
-			indMonth1, err1 := utils.ParseMonth(line1)
-			indMonth2, err2 := utils.ParseMonth(line2)
+// Sort метод для функционирования утилиты, который возвращает отсортированные данные, исходя из установленных опций
+// при запуске утилиты, в случае отсутствия ошибок
+func (sc *SortClient) Sort() []string {
+	return sc.sorter().SortLines(sc.data)
+}
 
-			if err1 != nil || err2 != nil {
-				return cmp.Compare(line1, line2)
-			}
+// Merge метод для слияния уже отсортированных входных файлов (опция -m) в writer без загрузки их в память целиком,
+// возвращает ошибку, если один из файлов оказался не отсортирован в соответствии с установленными опциями
+func (sc *SortClient) Merge(writer io.Writer) error {
+	defer sc.closeInputs()
 
-			return cmp.Compare(indMonth1, indMonth2)
-		}
+	return sc.sorter().Merge(writer, sc.inputs()...)
+}
 
-		return cmp.Compare(line1, line2)
-	})
+// IsSorted метод, возвращающий -1 в случае, если переданные данные отсортированы в соответствии с переданными
+// опциями, иначе индекс первой строки, нарушающей сортировку
+func (sc *SortClient) IsSorted() int {
+	return sc.sorter().IsSorted(sc.data)
+}
 
-	// учитывание опции -u
-	if sc.flags.unique {
-		result = utils.RemoveDuplicates(result)
-	}
+// Check метод для проверки отсортированности входного файла (опции -c, -C) за один проход, при нарушении сортировки
+// выводит в writer диагностическое сообщение о первой неотсортированной строке (кроме опции -C) и возвращает ошибку,
+// оборачивающую sorter.ErrDisorder
+func (sc *SortClient) Check(writer io.Writer) error {
+	defer sc.closeInputs()
 
-	// учитвание опции -r
-	if sc.flags.reverse {
-		slices.Reverse(result)
+	if len(sc.args.inputFiles) > 1 {
+		return errExtraOperand
 	}
 
-	return result
-}
-
-// IsSorted метод возвращающий -1 в случае, если переданные данные были отсортированы в соответсвии с переданными
-// опцииями, индекс строки, которая нарушает сортировку в соответсвии с переданными опцииями, или ошибку
-func (sc *SortClient) IsSorted() int {
-	sortedData := sc.Sort()
-
-	high := min(len(sortedData), len(sc.data))
+	err := sc.sorter().Check(sc.inputs()[0])
 
-	for i := 0; i < high; i++ {
-		if sc.data[i] != sortedData[i] {
-			return i
+	// учитывание опции -C
+	var disorderErr *sorter.DisorderError
+	if errors.As(err, &disorderErr) && !sc.flags.checkSilent {
+		_, writeErr := fmt.Fprintf(writer, "sort: %v\n", disorderErr)
+		if writeErr != nil {
+			return writeErr
 		}
 	}
 
-	return -1
+	return err
 }
 
 // Start метод запуска утилиты
 func (sc *SortClient) Start() error {
-	// учитывание опции -c
-	if sc.flags.checkSorted {
-		outputData := sc.IsSorted()
-		err := utils.WriteData(os.Stdout, outputData)
+	// учитывание опций -c, -C, вывод в таком случае не производится
+	if sc.flags.checkSorted || sc.flags.checkSilent {
+		return sc.Check(os.Stderr)
+	}
+
+	// учитывание опции -o, запись производится во временный файл, который заменяет выходной только после успешного
+	// завершения, поэтому выходной файл может совпадать с одним из входных
+	if sc.flags.output != "" {
+		outputFile, err := utils.CreateAtomic(sc.flags.output)
 		if err != nil {
 			return err
 		}
-	} else {
-		outputData := sc.Sort()
-		err := utils.WriteData(os.Stdout, outputData...)
+
+		err = sc.Write(outputFile)
 		if err != nil {
+			_ = outputFile.Abort()
 			return err
 		}
+
+		return outputFile.Commit()
 	}
 
-	return nil
+	return sc.Write(os.Stdout)
+}
+
+// Write метод для записи результата работы утилиты в writer через буфер
+func (sc *SortClient) Write(writer io.Writer) error {
+	// учитывание опции -m
+	if sc.flags.merge {
+		return sc.Merge(writer)
+	}
+
+	lines, err := sc.sortedLines()
+	if err != nil {
+		return err
+	}
+
+	// учитывание опции --debug
+	if sc.flags.debug {
+		return sc.sorter().Debug(writer, lines)
+	}
+
+	bufferedWriter := bufio.NewWriter(writer)
+
+	err = utils.WriteRecords(bufferedWriter, sc.flags.delimiter(), lines...)
+	if err != nil {
+		return err
+	}
+
+	return bufferedWriter.Flush()
+}
+
+// sortedLines метод, возвращающий отсортированные данные. При опциях --head, --tail, если данные не загружены в
+// память, они читаются из входных файлов с отбором строк
+func (sc *SortClient) sortedLines() ([]string, error) {
+	if sc.partial() && sc.data == nil {
+		defer sc.closeInputs()
+
+		return sc.sorter().Top(sc.inputs()...)
+	}
+
+	return sc.Sort(), nil
 }
 
 func main() {
@@ -318,8 +509,13 @@ func main() {
 		os.Exit(1)
 	}
 
-	// запуск утилиты, в случае ошибки - её вывод и выход из программы с кодом ошибки 1
+	// запуск утилиты, в случае ошибки - её вывод и выход из программы с кодом ошибки 1, при нарушении сортировки
+	// (опции -c, -C) диагностика уже выведена, поэтому только выход с кодом ошибки 1
 	err = sortClient.Start()
+	if errors.Is(err, sorter.ErrDisorder) {
+		os.Exit(1)
+	}
+
 	if err != nil {
 		fmt.Printf("%q\n", err)
 		os.Exit(1)
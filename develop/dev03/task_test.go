@@ -3,10 +3,17 @@ package main
 import (
 	"errors"
 	"flag"
+	"fmt"
+	"io"
+	"math"
 	"os"
+	"path/filepath"
 	"reflect"
+	"slices"
 	"strings"
 	"testing"
+	"time"
+	"wb-level-2/develop/dev03/sorter"
 	"wb-level-2/develop/dev03/utils"
 )
 
@@ -113,6 +120,132 @@ func TestSortFlags_Parse(t *testing.T) {
 				numericSuffix: true,
 			},
 		},
+		{
+			name: "General numeric flag",
+			args: []string{"-g"},
+			flags: SortFlags{
+				generalNumeric: true,
+			},
+		},
+		{
+			name: "Version flag",
+			args: []string{"-V"},
+			flags: SortFlags{
+				version: true,
+			},
+		},
+		{
+			name: "Merge flag",
+			args: []string{"-m"},
+			flags: SortFlags{
+				merge: true,
+			},
+		},
+		{
+			name: "Check silent flag",
+			args: []string{"-C"},
+			flags: SortFlags{
+				checkSilent: true,
+			},
+		},
+		{
+			name: "Locale flag",
+			args: []string{"--locale", "ru_RU.UTF-8"},
+			flags: SortFlags{
+				locale: "ru_RU.UTF-8",
+			},
+		},
+		{
+			name: "Fold case flag",
+			args: []string{"-f"},
+			flags: SortFlags{
+				foldCase: true,
+			},
+		},
+		{
+			name: "Dictionary order flag",
+			args: []string{"-d"},
+			flags: SortFlags{
+				dictionary: true,
+			},
+		},
+		{
+			name: "Ignore non-printing flag",
+			args: []string{"-i"},
+			flags: SortFlags{
+				ignoreNonPrint: true,
+			},
+		},
+		{
+			name: "Output flag",
+			args: []string{"-o", "sorted.txt"},
+			flags: SortFlags{
+				output: "sorted.txt",
+			},
+		},
+		{
+			name: "Zero terminated flag",
+			args: []string{"-z"},
+			flags: SortFlags{
+				zeroTerminated: true,
+			},
+		},
+		{
+			name: "Random flags",
+			args: []string{"-R", "--random-source", "/dev/urandom", "--seed", "42"},
+			flags: SortFlags{
+				random:       true,
+				randomSource: "/dev/urandom",
+				seed:         42,
+			},
+		},
+		{
+			name: "Shuffle flag",
+			args: []string{"--shuffle"},
+			flags: SortFlags{
+				shuffle: true,
+			},
+		},
+		{
+			name: "Structured format flags",
+			args: []string{"--format", "csv", "--key", "name,2", "--key", "age", "--no-header"},
+			flags: SortFlags{
+				format:   "csv",
+				keys:     []string{"name", "2", "age"},
+				noHeader: true,
+			},
+		},
+		{
+			name: "Head flag",
+			args: []string{"--head", "5"},
+			flags: SortFlags{
+				head: 5,
+			},
+		},
+		{
+			name: "Debug flag",
+			args: []string{"--debug"},
+			flags: SortFlags{
+				debug: true,
+			},
+		},
+		{
+			name: "Time key flags",
+			args: []string{"--time-key", "--time-layout", "%Y-%m-%d", "--time-unparsed", "first"},
+			flags: SortFlags{
+				timeKey:      true,
+				timeLayout:   "%Y-%m-%d",
+				timeUnparsed: "first",
+			},
+		},
+		{
+			name: "SI flag",
+			args: []string{"-h", "--si"},
+			flags: SortFlags{
+				numericSuffix: true,
+				si:            true,
+			},
+		},
 		{
 			name:  "Invalid flag",
 			args:  []string{"-x"},
@@ -569,6 +702,252 @@ func TestSortClient_Sort(t *testing.T) {
 				"h 7o",
 			},
 		},
+		{
+			name: "Sort with numeric suffix flag like GNU sort",
+			args: []string{"-h"},
+			data: []string{
+				"1K",
+				"1023",
+				"-2M",
+				"-1K",
+				"1.5M",
+				"2P",
+				"1E",
+				"0",
+				"-5",
+				"900G",
+				"1T",
+			},
+			expectedResult: []string{
+				"-2M",
+				"-1K",
+				"-5",
+				"0",
+				"1023",
+				"1K",
+				"1.5M",
+				"900G",
+				"1T",
+				"2P",
+				"1E",
+			},
+		},
+		{
+			name: "Sort with numeric suffix flag and IEC and byte suffixes",
+			args: []string{"-h"},
+			data: []string{
+				"3MiB",
+				"512b",
+				"2Ki",
+				"1.5GB",
+				"4kb",
+			},
+			expectedResult: []string{
+				"512b",
+				"2Ki",
+				"4kb",
+				"3MiB",
+				"1.5GB",
+			},
+		},
+		{
+			name: "Sort with numeric suffix flag and numbers without suffix",
+			args: []string{"-h"},
+			data: []string{
+				"1K",
+				"2000",
+				"1024",
+				"500",
+			},
+			expectedResult: []string{
+				"500",
+				"1024",
+				"2000",
+				"1K",
+			},
+		},
+		{
+			name: "Sort with numeric suffix and SI flag",
+			args: []string{"-h", "--si"},
+			data: []string{
+				"1K",
+				"1010",
+				"1.5M",
+				"1499K",
+			},
+			expectedResult: []string{
+				"1010",
+				"1K",
+				"1499K",
+				"1.5M",
+			},
+		},
+		{
+			name: "Sort with numeric flag and negative and thousands-separated numbers",
+			args: []string{"-n"},
+			data: []string{
+				"10",
+				"-20",
+				"1,500",
+				"-3.5",
+				"200",
+			},
+			expectedResult: []string{
+				"-20",
+				"-3.5",
+				"10",
+				"200",
+				"1,500",
+			},
+		},
+		{
+			name: "Sort with general numeric flag",
+			args: []string{"-g"},
+			data: []string{
+				"1e3",
+				"-5",
+				"abc",
+				"0x10",
+				"inf",
+				"-inf",
+				"nan",
+				"2.5",
+				"+7",
+			},
+			expectedResult: []string{
+				"abc",
+				"nan",
+				"-inf",
+				"-5",
+				"2.5",
+				"+7",
+				"0x10",
+				"1e3",
+				"inf",
+			},
+		},
+		{
+			name: "Sort with version flag",
+			args: []string{"-V"},
+			data: []string{
+				"1.10.2",
+				"1.9",
+				"1.9.1",
+				"1.10",
+				"1.2-rc1",
+				"1.2",
+				"1.2~rc1",
+			},
+			expectedResult: []string{
+				"1.2~rc1",
+				"1.2",
+				"1.2-rc1",
+				"1.9",
+				"1.9.1",
+				"1.10",
+				"1.10.2",
+			},
+		},
+		{
+			name: "Sort with locale flag",
+			args: []string{"--locale", "ru_RU.UTF-8"},
+			data: []string{
+				"яблоко",
+				"ёлка",
+				"жук",
+				"Елена",
+				"ель",
+				"арбуз",
+			},
+			expectedResult: []string{
+				"арбуз",
+				"Елена",
+				"ёлка",
+				"ель",
+				"жук",
+				"яблоко",
+			},
+		},
+		{
+			name: "Sort with fold case flag",
+			args: []string{"-f"},
+			data: []string{
+				"banana",
+				"Cherry",
+				"apple",
+				"Banana2",
+			},
+			expectedResult: []string{
+				"apple",
+				"banana",
+				"Banana2",
+				"Cherry",
+			},
+		},
+		{
+			name: "Sort with dictionary order flag",
+			args: []string{"-d"},
+			data: []string{
+				"#c",
+				"-b",
+				"a",
+				"_d",
+			},
+			expectedResult: []string{
+				"a",
+				"-b",
+				"#c",
+				"_d",
+			},
+		},
+		{
+			name: "Sort with ignore non-printing flag",
+			args: []string{"-i"},
+			data: []string{
+				"\x01c",
+				"b",
+				"\x7fa",
+			},
+			expectedResult: []string{
+				"\x7fa",
+				"b",
+				"\x01c",
+			},
+		},
+		{
+			name: "Sort with month flag and russian month names",
+			args: []string{"-M"},
+			data: []string{
+				"Декабрь",
+				"мая",
+				"январь",
+				"Март",
+				"сентябрь",
+			},
+			expectedResult: []string{
+				"январь",
+				"Март",
+				"мая",
+				"сентябрь",
+				"Декабрь",
+			},
+		},
+		{
+			name: "Sort with columns and version flag",
+			args: []string{"-k", "2", "-V"},
+			data: []string{
+				"app-b v2.0.10",
+				"app-a v2.0.9",
+				"app-c v10.1",
+				"app-d v2.0",
+			},
+			expectedResult: []string{
+				"app-d v2.0",
+				"app-a v2.0.9",
+				"app-b v2.0.10",
+				"app-c v10.1",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -584,6 +963,11 @@ func TestSortClient_Sort(t *testing.T) {
 				t.Errorf("not expected error: %q", err)
 			}
 
+			sc.collator, err = utils.NewCollator(sc.flags.locale)
+			if err != nil {
+				t.Errorf("not expected error: %q", err)
+			}
+
 			sc.data = tt.data
 
 			result := sc.Sort()
@@ -625,81 +1009,1031 @@ func TestSortClient_Sort2(t *testing.T) {
 	})
 }
 
-func TestSortClient_IsSorted(t *testing.T) {
-	t.Run("Data sorted", func(t *testing.T) {
-		sc := &SortClient{
-			flags: SortFlags{},
-			data:  []string{"apple", "banana", "cherry"},
-		}
+// Helper function to create input files with the given contents.
+func createInputFiles(t *testing.T, contents ...string) []*os.File {
+	t.Helper()
 
-		index := sc.IsSorted()
+	dir := t.TempDir()
+	files := make([]*os.File, 0, len(contents))
 
-		if index != -1 {
-			t.Errorf("expected -1, got %d", index)
-		}
-	})
+	for i, content := range contents {
+		name := filepath.Join(dir, fmt.Sprintf("input%d.txt", i))
 
-	t.Run("Data not sorted", func(t *testing.T) {
-		sc := &SortClient{
-			flags: SortFlags{},
-			data:  []string{"banana", "apple", "cherry"},
+		err := os.WriteFile(name, []byte(content), 0o644)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
 		}
 
-		expectedIndex := 0
+		file, err := os.Open(name)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
 
-		index := sc.IsSorted()
+		files = append(files, file)
+	}
 
-		if index != expectedIndex {
-			t.Errorf("expected %d, got %d", expectedIndex, index)
-		}
-	})
+	return files
 }
 
-func TestReadData(t *testing.T) {
-	t.Run("Read data", func(t *testing.T) {
-		input := strings.NewReader("line1\nline2\nline3")
-		expected := []string{"line1", "line2", "line3"}
+// Expected results are the output of GNU sort, where -k N is -k N,N.
+func TestSortClient_SortUnique(t *testing.T) {
+	tests := []struct {
+		name           string
+		flags          SortFlags
+		data           []string
+		expectedResult []string
+	}{
+		{
+			name:           "Unique by column",
+			flags:          SortFlags{unique: true, columns: IntSlice{2}},
+			data:           []string{"b 1", "a 1", "c 0", "d 0", "e 2"},
+			expectedResult: []string{"c 0", "b 1", "e 2"},
+		},
+		{
+			name:           "Unique by column in reverse order",
+			flags:          SortFlags{unique: true, reverse: true, columns: IntSlice{2}},
+			data:           []string{"b 1", "a 1", "c 0", "d 0", "e 2"},
+			expectedResult: []string{"e 2", "b 1", "c 0"},
+		},
+		{
+			name:           "Unique with fold case",
+			flags:          SortFlags{unique: true, foldCase: true},
+			data:           []string{"Apple", "banana", "apple", "APPLE", "Banana", "cherry"},
+			expectedResult: []string{"Apple", "banana", "cherry"},
+		},
+		{
+			name:           "Unique with fold case in reverse order",
+			flags:          SortFlags{unique: true, foldCase: true, reverse: true},
+			data:           []string{"Apple", "banana", "apple", "APPLE", "Banana", "cherry"},
+			expectedResult: []string{"cherry", "banana", "Apple"},
+		},
+		{
+			name:           "Unique with numeric",
+			flags:          SortFlags{unique: true, numeric: true},
+			data:           []string{"1", "01", "1.0", "2", "02", "10", "010"},
+			expectedResult: []string{"1", "2", "10"},
+		},
+		{
+			name:           "Unique with numeric in reverse order",
+			flags:          SortFlags{unique: true, numeric: true, reverse: true},
+			data:           []string{"1", "01", "1.0", "2", "02", "10", "010"},
+			expectedResult: []string{"10", "2", "1"},
+		},
+		{
+			name:           "Unique with month",
+			flags:          SortFlags{unique: true, month: true},
+			data:           []string{"jan", "JANUARY", "feb", "February", "mar"},
+			expectedResult: []string{"jan", "feb", "mar"},
+		},
+		{
+			name:           "Unique by column with numeric",
+			flags:          SortFlags{unique: true, numeric: true, columns: IntSlice{2}},
+			data:           []string{"x 5", "y 05", "z 3", "w 3.0"},
+			expectedResult: []string{"z 3", "x 5"},
+		},
+		{
+			name:           "Unique with ignore spaces",
+			flags:          SortFlags{unique: true, ignoreSpaces: true},
+			data:           []string{"  a", "a", " b", "b"},
+			expectedResult: []string{"  a", " b"},
+		},
+	}
 
-		result, err := utils.ReadData(input)
-		if err != nil {
-			t.Errorf("unexpected error: %v", err)
-		}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := &SortClient{flags: tt.flags, data: tt.data}
 
-		if !reflect.DeepEqual(result, expected) {
-			t.Errorf("got %v, want %v", result, expected)
-		}
-	})
+			result := sc.Sort()
+
+			if !reflect.DeepEqual(result, tt.expectedResult) {
+				t.Errorf("got %v, expected %v", result, tt.expectedResult)
+			}
+		})
+	}
 }
 
-func TestWriteData(t *testing.T) {
-	t.Run("Write data", func(t *testing.T) {
-		var output strings.Builder
-		data := []string{"line1", "line2", "line3"}
+func TestSortClient_SortRandom(t *testing.T) {
+	data := []string{"a 1", "b 2", "c 3", "d 1", "e 2", "f 3", "g 1", "h 4", "i 5", "j 4"}
 
-		err := utils.WriteData(&output, data...)
-		if err != nil {
-			t.Errorf("unexpected error: %v", err)
+	sortRandom := func(seed uint64) []string {
+		sc := &SortClient{
+			flags: SortFlags{random: true, columns: IntSlice{2}},
+			data:  data,
+			seed:  seed,
 		}
 
-		expected := "line1\nline2\nline3\n"
+		return sc.Sort()
+	}
 
-		if output.String() != expected {
-			t.Errorf("got %q, want %q", output.String(), expected)
-		}
-	})
-}
+	t.Run("Equal keys are grouped", func(t *testing.T) {
+		result := sortRandom(1)
+		seen := make(map[string]bool)
 
-func TestTrimLeadingSpaces(t *testing.T) {
-	t.Run("Trim leading spaces", func(t *testing.T) {
-		input := "   \n\ttrim spaces  "
-		expected := "trim spaces  "
+		for i, line := range result {
+			key := strings.Fields(line)[1]
 
-		result := utils.TrimLeadingSpaces(input)
-		if result != expected {
-			t.Errorf("got %q, want %q", result, expected)
+			if seen[key] && strings.Fields(result[i-1])[1] != key {
+				t.Errorf("key %q is not grouped in %v", key, result)
+			}
+
+			seen[key] = true
 		}
 	})
-}
+
+	t.Run("Same seed gives same order", func(t *testing.T) {
+		if !reflect.DeepEqual(sortRandom(7), sortRandom(7)) {
+			t.Errorf("got different orders for the same seed")
+		}
+	})
+
+	t.Run("Different seeds give different orders", func(t *testing.T) {
+		first := sortRandom(0)
+
+		for seed := uint64(1); seed < 10; seed++ {
+			if !reflect.DeepEqual(first, sortRandom(seed)) {
+				return
+			}
+		}
+
+		t.Errorf("got the same order for all seeds")
+	})
+
+	t.Run("Shuffle", func(t *testing.T) {
+		sc := &SortClient{
+			flags: SortFlags{shuffle: true},
+			data:  data,
+			seed:  3,
+		}
+
+		result := sc.Sort()
+
+		if !reflect.DeepEqual(result, sc.Sort()) {
+			t.Errorf("got different orders for the same seed")
+		}
+
+		sorted := slices.Clone(result)
+		slices.Sort(sorted)
+
+		if !reflect.DeepEqual(sorted, data) {
+			t.Errorf("got %v, want permutation of %v", result, data)
+		}
+	})
+}
+
+func TestSortClient_SortTime(t *testing.T) {
+	tests := []struct {
+		name       string
+		flags      SortFlags
+		timeLayout string
+		data       []string
+		expected   []string
+	}{
+		{
+			name:  "Auto detected layouts",
+			flags: SortFlags{timeKey: true},
+			data: []string{
+				"2023-05-01T10:00:00+03:00",
+				"not a time",
+				"Mon, 01 May 2023 06:30:00 GMT",
+				"1682920800",
+				"2023-05-01T06:00:00.5Z",
+			},
+			expected: []string{
+				"1682920800",
+				"2023-05-01T06:00:00.5Z",
+				"Mon, 01 May 2023 06:30:00 GMT",
+				"2023-05-01T10:00:00+03:00",
+				"not a time",
+			},
+		},
+		{
+			name:     "Unparsed last with reverse",
+			flags:    SortFlags{timeKey: true, reverse: true},
+			data:     []string{"x", "1", "3", "2"},
+			expected: []string{"3", "2", "1", "x"},
+		},
+		{
+			name:     "Unparsed first",
+			flags:    SortFlags{timeKey: true, timeUnparsed: "first", unique: true},
+			data:     []string{"3", "y", "1", "x", "1.0"},
+			expected: []string{"x", "y", "1", "3"},
+		},
+		{
+			name:       "Layout and columns",
+			flags:      SortFlags{timeKey: true, columns: IntSlice{2, 3}},
+			timeLayout: "02.01.2006 15:04",
+			data:       []string{"b 01.02.2023 09:00", "a 02.01.2023 10:00", "c 01.02.2023 08:00"},
+			expected:   []string{"a 02.01.2023 10:00", "c 01.02.2023 08:00", "b 01.02.2023 09:00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := &SortClient{
+				flags:      tt.flags,
+				data:       tt.data,
+				timeLayout: tt.timeLayout,
+			}
+
+			result := sc.Sort()
+
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("got %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSortFlags_randomSeed(t *testing.T) {
+	t.Run("Seed flag", func(t *testing.T) {
+		sf := SortFlags{seed: 42}
+
+		seed, err := sf.randomSeed()
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if seed != 42 {
+			t.Errorf("got %d, want 42", seed)
+		}
+	})
+
+	t.Run("Random source flag", func(t *testing.T) {
+		inputFiles := createInputFiles(t, "\x01\x00\x00\x00\x00\x00\x00\x00tail")
+		sf := SortFlags{randomSource: inputFiles[0].Name(), seed: 42}
+
+		seed, err := sf.randomSeed()
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if seed != 1 {
+			t.Errorf("got %d, want 1", seed)
+		}
+	})
+
+	t.Run("Short random source", func(t *testing.T) {
+		inputFiles := createInputFiles(t, "\x01")
+		sf := SortFlags{randomSource: inputFiles[0].Name()}
+
+		_, err := sf.randomSeed()
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("got %v, want %v", err, io.ErrUnexpectedEOF)
+		}
+	})
+}
+
+func TestSortClient_Merge(t *testing.T) {
+	tests := []struct {
+		name     string
+		flags    SortFlags
+		contents []string
+		expected string
+		hasErr   bool
+	}{
+		{
+			name:     "Merge with no flags",
+			contents: []string{"a\nc\ne\n", "b\nd\nf\n", "", "a\ng\n"},
+			expected: "a\na\nb\nc\nd\ne\nf\ng\n",
+		},
+		{
+			name:     "Merge with columns and numeric flag",
+			flags:    SortFlags{columns: IntSlice{2}, numeric: true},
+			contents: []string{"x 1\ny 10\n", "z 2\nw 100\n"},
+			expected: "x 1\nz 2\ny 10\nw 100\n",
+		},
+		{
+			name:     "Merge with reverse and unique flag",
+			flags:    SortFlags{reverse: true, unique: true},
+			contents: []string{"c\nb\na\n", "c\na\n"},
+			expected: "c\nb\na\n",
+		},
+		{
+			name:     "Merge with columns and unique flag",
+			flags:    SortFlags{columns: IntSlice{2}, unique: true},
+			contents: []string{"a 1\nb 2\n", "c 1\nd 3\n"},
+			expected: "a 1\nb 2\nd 3\n",
+		},
+		{
+			name:     "Merge CSV with header and reverse flag",
+			flags:    SortFlags{format: "csv", reverse: true},
+			contents: []string{"name,v\nz,1\na,2\n", "name,v\ny,1\n"},
+			expected: "name,v\nz,1\ny,1\na,2\n",
+		},
+		{
+			name:     "Merge CSV with unsorted input after header",
+			flags:    SortFlags{format: "csv"},
+			contents: []string{"name,v\nb,1\na,2\n"},
+			expected: "name,v\nb,1\n",
+			hasErr:   true,
+		},
+		{
+			name:     "Merge unsorted input",
+			contents: []string{"a\nc\n", "d\nb\n"},
+			expected: "a\nc\nd\n",
+			hasErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := &SortClient{flags: tt.flags}
+			sc.flags.merge = true
+			sc.args.inputFiles = createInputFiles(t, tt.contents...)
+
+			var output strings.Builder
+
+			err := sc.Merge(&output)
+			if (err != nil) != tt.hasErr {
+				t.Errorf("SortClient.Merge() error = %v, wantErr %v", err, tt.hasErr)
+			}
+
+			if tt.hasErr && !errors.Is(err, sorter.ErrUnsorted) {
+				t.Errorf("got %v, want %v", err, sorter.ErrUnsorted)
+			}
+
+			if output.String() != tt.expected {
+				t.Errorf("got %q, want %q", output.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestSortClient_Write(t *testing.T) {
+	t.Run("Zero terminated records", func(t *testing.T) {
+		sc := &SortClient{
+			flags: SortFlags{zeroTerminated: true},
+			data:  []string{"b\nline", "a\nline"},
+		}
+
+		var output strings.Builder
+
+		err := sc.Write(&output)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		expected := "a\nline\x00b\nline\x00"
+
+		if output.String() != expected {
+			t.Errorf("got %q, want %q", output.String(), expected)
+		}
+	})
+}
+
+func TestSortClient_WritePartial(t *testing.T) {
+	tests := []struct {
+		name     string
+		flags    SortFlags
+		input    string
+		expected string
+	}{
+		{
+			name:     "Head with numeric flag",
+			flags:    SortFlags{numeric: true, head: 3},
+			input:    "5\n3\n9\n1\n7\n3\n",
+			expected: "1\n3\n3\n",
+		},
+		{
+			name:     "Tail with reverse flag",
+			flags:    SortFlags{numeric: true, reverse: true, tail: 2},
+			input:    "5\n3\n9\n1\n7\n3\n",
+			expected: "3\n1\n",
+		},
+		{
+			name:     "Head with unique flag",
+			flags:    SortFlags{numeric: true, unique: true, head: 3},
+			input:    "5\n3\n9\n1\n7\n3\n",
+			expected: "1\n3\n5\n",
+		},
+		{
+			name:     "Tail with unique flag keeps first line of equal keys",
+			flags:    SortFlags{columns: IntSlice{1}, unique: true, tail: 2},
+			input:    "b 1\na 2\nb 3\nc 4\n",
+			expected: "b 1\nc 4\n",
+		},
+		{
+			name:     "Head larger than input",
+			flags:    SortFlags{head: 10},
+			input:    "c\na\nb\n",
+			expected: "a\nb\nc\n",
+		},
+		{
+			name:     "Head with CSV header",
+			flags:    SortFlags{format: "csv", keys: []string{"size"}, numeric: true, head: 2},
+			input:    "name,size\nx,3\ny,1\nz,2\n",
+			expected: "name,size\ny,1\nz,2\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := &SortClient{
+				flags: tt.flags,
+				args:  SortArgs{inputFiles: createInputFiles(t, tt.input)},
+			}
+
+			var output strings.Builder
+
+			err := sc.Write(&output)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if output.String() != tt.expected {
+				t.Errorf("got %q, want %q", output.String(), tt.expected)
+			}
+
+			sc.data = strings.Split(strings.TrimSuffix(tt.input, "\n"), "\n")
+
+			result := strings.Join(sc.Sort(), "\n") + "\n"
+			if result != tt.expected {
+				t.Errorf("got %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSortClient_WriteDebug(t *testing.T) {
+	tests := []struct {
+		name     string
+		flags    SortFlags
+		data     []string
+		expected string
+	}{
+		{
+			name:     "Whole line key",
+			flags:    SortFlags{},
+			data:     []string{"b", "a"},
+			expected: "a\n_\nb\n_\n",
+		},
+		{
+			name:     "Column keys with tabs and missing column",
+			flags:    SortFlags{columns: IntSlice{2, 3}},
+			data:     []string{"a\tb c", "x", "я 10 ж"},
+			expected: "x\n^ no match for key\nя 10 ж\n  __ _\na\tb c\n \t_ _\n",
+		},
+		{
+			name:     "Ignore leading blanks",
+			flags:    SortFlags{ignoreSpaces: true},
+			data:     []string{"  b"},
+			expected: "  b\n  _\n",
+		},
+		{
+			name:     "Numeric fallback warning",
+			flags:    SortFlags{numeric: true},
+			data:     []string{"10", "abc"},
+			expected: "10\n__\nabc\n___\n^ no number, compared as text\n",
+		},
+		{
+			name:     "Month fallback warning",
+			flags:    SortFlags{month: true},
+			data:     []string{"Feb", "x"},
+			expected: "Feb\n___\nx\n_\n^ no month name, compared as text\n",
+		},
+		{
+			name:     "CSV keys",
+			flags:    SortFlags{format: "csv", keys: []string{"size"}, numeric: true},
+			data:     []string{"name,size", "x,3", "y,1"},
+			expected: "name,size\ny,1\n^ column 2: \"1\"\nx,3\n^ column 2: \"3\"\n",
+		},
+		{
+			name:     "JSON Lines keys",
+			flags:    SortFlags{format: "jsonl", keys: []string{"/id"}},
+			data:     []string{`{"id":2}`, `{}`},
+			expected: "{}\n^ no match for key\n{\"id\":2}\n^ key: 2\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.flags.debug = true
+
+			sc := &SortClient{
+				flags: tt.flags,
+				data:  tt.data,
+			}
+
+			var output strings.Builder
+
+			err := sc.Write(&output)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if output.String() != tt.expected {
+				t.Errorf("got %q, want %q", output.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestSortClient_Start(t *testing.T) {
+	t.Run("Sort file in place", func(t *testing.T) {
+		inputFiles := createInputFiles(t, "c\nb\na\n")
+		name := inputFiles[0].Name()
+
+		err := os.Chmod(name, 0o600)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		data, err := utils.ReadData(inputFiles[0])
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		sc := &SortClient{
+			flags: SortFlags{output: name},
+			args:  SortArgs{inputFiles: inputFiles},
+			data:  data,
+		}
+
+		err = sc.Start()
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		result, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if string(result) != "a\nb\nc\n" {
+			t.Errorf("got %q, want %q", result, "a\nb\nc\n")
+		}
+
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if info.Mode().Perm() != 0o600 {
+			t.Errorf("got mode %v, want %v", info.Mode().Perm(), os.FileMode(0o600))
+		}
+
+		entries, err := os.ReadDir(filepath.Dir(name))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(entries) != 1 {
+			t.Errorf("got %d files in directory, want 1", len(entries))
+		}
+	})
+
+	t.Run("Merge into one of the inputs", func(t *testing.T) {
+		inputFiles := createInputFiles(t, "a\nc\n", "b\nd\n")
+		name := inputFiles[0].Name()
+
+		sc := &SortClient{
+			flags: SortFlags{merge: true, output: name},
+			args:  SortArgs{inputFiles: inputFiles},
+		}
+
+		err := sc.Start()
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		result, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if string(result) != "a\nb\nc\nd\n" {
+			t.Errorf("got %q, want %q", result, "a\nb\nc\nd\n")
+		}
+	})
+}
+
+func TestSortClient_IsSorted(t *testing.T) {
+	t.Run("Data sorted", func(t *testing.T) {
+		sc := &SortClient{
+			flags: SortFlags{},
+			data:  []string{"apple", "banana", "cherry"},
+		}
+
+		index := sc.IsSorted()
+
+		if index != -1 {
+			t.Errorf("expected -1, got %d", index)
+		}
+	})
+
+	t.Run("Data not sorted", func(t *testing.T) {
+		sc := &SortClient{
+			flags: SortFlags{},
+			data:  []string{"banana", "apple", "cherry"},
+		}
+
+		expectedIndex := 1
+
+		index := sc.IsSorted()
+
+		if index != expectedIndex {
+			t.Errorf("expected %d, got %d", expectedIndex, index)
+		}
+	})
+
+	t.Run("Data sorted in reverse order", func(t *testing.T) {
+		sc := &SortClient{
+			flags: SortFlags{reverse: true, columns: IntSlice{2}, numeric: true},
+			data:  []string{"a 10", "b 9", "c 9", "d 1"},
+		}
+
+		index := sc.IsSorted()
+
+		if index != -1 {
+			t.Errorf("expected -1, got %d", index)
+		}
+	})
+
+	t.Run("Data with duplicates and unique flag", func(t *testing.T) {
+		sc := &SortClient{
+			flags: SortFlags{unique: true},
+			data:  []string{"apple", "banana", "banana", "cherry"},
+		}
+
+		expectedIndex := 2
+
+		index := sc.IsSorted()
+
+		if index != expectedIndex {
+			t.Errorf("expected %d, got %d", expectedIndex, index)
+		}
+	})
+}
+
+func TestSortClient_Check(t *testing.T) {
+	tests := []struct {
+		name     string
+		flags    SortFlags
+		contents []string
+		expected string
+		err      error
+	}{
+		{
+			name:     "Sorted data",
+			flags:    SortFlags{checkSorted: true},
+			contents: []string{"apple\nbanana\ncherry\n"},
+			expected: "",
+			err:      nil,
+		},
+		{
+			name:     "Disorder",
+			flags:    SortFlags{checkSorted: true},
+			contents: []string{"apple\ncherry\nbanana\n"},
+			expected: "sort: %s:3: disorder: banana\n",
+			err:      sorter.ErrDisorder,
+		},
+		{
+			name:     "Silent disorder",
+			flags:    SortFlags{checkSilent: true},
+			contents: []string{"apple\ncherry\nbanana\n"},
+			expected: "",
+			err:      sorter.ErrDisorder,
+		},
+		{
+			name:     "Extra operand",
+			flags:    SortFlags{checkSorted: true},
+			contents: []string{"apple\n", "banana\n"},
+			expected: "",
+			err:      errExtraOperand,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := &SortClient{flags: tt.flags}
+			sc.args.inputFiles = createInputFiles(t, tt.contents...)
+
+			var output strings.Builder
+
+			err := sc.Check(&output)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("got %v, want %v", err, tt.err)
+			}
+
+			expected := tt.expected
+			if strings.Contains(expected, "%s") {
+				expected = fmt.Sprintf(expected, sc.args.inputFiles[0].Name())
+			}
+
+			if output.String() != expected {
+				t.Errorf("got %q, want %q", output.String(), expected)
+			}
+		})
+	}
+}
+
+func TestSorter(t *testing.T) {
+	t.Run("Sort readers", func(t *testing.T) {
+		srt := sorter.New(sorter.SortOptions{Columns: []int{2}, Numeric: true, Reverse: true})
+
+		var output strings.Builder
+
+		err := srt.Sort(&output, strings.NewReader("a 1\nb 10\n"), strings.NewReader("c 2\n"))
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		expected := "b 10\nc 2\na 1\n"
+
+		if output.String() != expected {
+			t.Errorf("got %q, want %q", output.String(), expected)
+		}
+	})
+
+	t.Run("Merge readers", func(t *testing.T) {
+		srt := sorter.New(sorter.SortOptions{Unique: true})
+
+		var output strings.Builder
+
+		err := srt.Merge(&output, strings.NewReader("a\nc\n"), strings.NewReader("a\nb\n"))
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		expected := "a\nb\nc\n"
+
+		if output.String() != expected {
+			t.Errorf("got %q, want %q", output.String(), expected)
+		}
+	})
+
+	t.Run("Check named reader", func(t *testing.T) {
+		srt := sorter.New(sorter.SortOptions{})
+
+		err := srt.Check(sorter.NamedReader("data.txt", strings.NewReader("b\na\n")))
+
+		var disorderErr *sorter.DisorderError
+		if !errors.As(err, &disorderErr) {
+			t.Fatalf("got %v, want %T", err, disorderErr)
+		}
+
+		expected := "data.txt:2: disorder: a"
+
+		if disorderErr.Error() != expected {
+			t.Errorf("got %q, want %q", disorderErr.Error(), expected)
+		}
+	})
+
+	t.Run("Sort CSV by column name", func(t *testing.T) {
+		srt := sorter.New(sorter.SortOptions{Format: sorter.FormatCSV, Keys: []string{"age", "name"}, Numeric: true})
+
+		var output strings.Builder
+
+		input := "name,age\n\"Smith, John\",42\nalice,7\n\"multi\nline\",42\n"
+
+		err := srt.Sort(&output, strings.NewReader(input), strings.NewReader("name,age\nbob,10\n"))
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		expected := "name,age\nalice,7\nbob,10\n\"Smith, John\",42\n\"multi\nline\",42\n"
+
+		if output.String() != expected {
+			t.Errorf("got %q, want %q", output.String(), expected)
+		}
+	})
+
+	t.Run("Sort CSV by column number without header", func(t *testing.T) {
+		srt := sorter.New(sorter.SortOptions{Format: sorter.FormatCSV, Keys: []string{"2"}, NoHeader: true})
+
+		var output strings.Builder
+
+		err := srt.Sort(&output, strings.NewReader("x,b\ny,a\n"))
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		expected := "y,a\nx,b\n"
+
+		if output.String() != expected {
+			t.Errorf("got %q, want %q", output.String(), expected)
+		}
+	})
+
+	t.Run("Sort CSV by unknown column", func(t *testing.T) {
+		srt := sorter.New(sorter.SortOptions{Format: sorter.FormatCSV, Keys: []string{"email"}})
+
+		err := srt.Sort(io.Discard, strings.NewReader("name,age\nbob,10\n"))
+		if !errors.Is(err, sorter.ErrUnknownColumn) {
+			t.Errorf("got %v, want %v", err, sorter.ErrUnknownColumn)
+		}
+	})
+
+	t.Run("Merge CSV", func(t *testing.T) {
+		srt := sorter.New(sorter.SortOptions{Format: sorter.FormatCSV, Keys: []string{"id"}, Numeric: true})
+
+		var output strings.Builder
+
+		err := srt.Merge(&output, strings.NewReader("id,v\n1,a\n10,b\n"), strings.NewReader("id,v\n2,c\n"))
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		expected := "id,v\n1,a\n2,c\n10,b\n"
+
+		if output.String() != expected {
+			t.Errorf("got %q, want %q", output.String(), expected)
+		}
+	})
+
+	t.Run("Sort JSON Lines by pointer", func(t *testing.T) {
+		srt := sorter.New(sorter.SortOptions{Format: sorter.FormatJSONL, Keys: []string{"/user/id", "/ts"}})
+
+		lines := []string{
+			`{"user":{"id":10},"ts":"b"}`,
+			`{"user":{"id":"7"},"ts":"a"}`,
+			`{"user":{"id":9},"ts":"a"}`,
+			`{"user":{},"ts":"z"}`,
+			`{"user":{"id":10},"ts":"a"}`,
+			`{"user":{"id":null}}`,
+		}
+
+		expected := []string{
+			`{"user":{},"ts":"z"}`,
+			`{"user":{"id":null}}`,
+			`{"user":{"id":9},"ts":"a"}`,
+			`{"user":{"id":10},"ts":"a"}`,
+			`{"user":{"id":10},"ts":"b"}`,
+			`{"user":{"id":"7"},"ts":"a"}`,
+		}
+
+		result := srt.SortLines(lines)
+
+		if !reflect.DeepEqual(result, expected) {
+			t.Errorf("got %v, want %v", result, expected)
+		}
+	})
+
+	t.Run("Sort JSON Lines with escaped pointer", func(t *testing.T) {
+		srt := sorter.New(sorter.SortOptions{Format: sorter.FormatJSONL, Keys: []string{"/a~1b/0"}, Reverse: true})
+
+		lines := []string{`{"a/b":[1]}`, `{"a/b":[3]}`, `{"a/b":[2]}`}
+		expected := []string{`{"a/b":[3]}`, `{"a/b":[2]}`, `{"a/b":[1]}`}
+
+		result := srt.SortLines(lines)
+
+		if !reflect.DeepEqual(result, expected) {
+			t.Errorf("got %v, want %v", result, expected)
+		}
+	})
+
+	t.Run("Top matches sort and head", func(t *testing.T) {
+		data := []string{"a 1", "b 1", "e 2", "c 1", "f 2", "d 1", "g 3"}
+
+		tests := []struct {
+			name    string
+			options sorter.SortOptions
+		}{
+			{name: "Head", options: sorter.SortOptions{Columns: []int{2}, Head: 3}},
+			{name: "Head with reverse", options: sorter.SortOptions{Columns: []int{2}, Reverse: true, Head: 3}},
+			{name: "Tail with reverse", options: sorter.SortOptions{Columns: []int{2}, Reverse: true, Tail: 4}},
+			{name: "Head with unique", options: sorter.SortOptions{Columns: []int{2}, Unique: true, Head: 2}},
+			{
+				name:    "Tail with unique and reverse",
+				options: sorter.SortOptions{Columns: []int{2}, Unique: true, Reverse: true, Tail: 2},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				fullOptions := tt.options
+				fullOptions.Head, fullOptions.Tail = 0, 0
+
+				expected := sorter.New(fullOptions).SortLines(data)
+				if tt.options.Head > 0 {
+					expected = expected[:tt.options.Head]
+				} else {
+					expected = expected[len(expected)-tt.options.Tail:]
+				}
+
+				result, err := sorter.New(tt.options).Top(strings.NewReader(strings.Join(data, "\n") + "\n"))
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if !reflect.DeepEqual(result, expected) {
+					t.Errorf("got %v, want %v", result, expected)
+				}
+			})
+		}
+	})
+
+	t.Run("Top breaks ties by whole line", func(t *testing.T) {
+		input := "d 1\nb 1\na 1\nc 1\ne 2\n"
+
+		tests := []struct {
+			name     string
+			options  sorter.SortOptions
+			expected []string
+		}{
+			{
+				name:     "Head with reverse",
+				options:  sorter.SortOptions{Columns: []int{2}, Reverse: true, Head: 3},
+				expected: []string{"e 2", "d 1", "c 1"},
+			},
+			{
+				name:     "Tail",
+				options:  sorter.SortOptions{Columns: []int{2}, Tail: 3},
+				expected: []string{"c 1", "d 1", "e 2"},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				result, err := sorter.New(tt.options).Top(strings.NewReader(input))
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if !reflect.DeepEqual(result, tt.expected) {
+					t.Errorf("got %v, want %v", result, tt.expected)
+				}
+			})
+		}
+	})
+
+	t.Run("Parse format", func(t *testing.T) {
+		_, err := sorter.ParseFormat("xml")
+		if !errors.Is(err, sorter.ErrUnknownFormat) {
+			t.Errorf("got %v, want %v", err, sorter.ErrUnknownFormat)
+		}
+	})
+
+	t.Run("Compare", func(t *testing.T) {
+		srt := sorter.New(sorter.SortOptions{Month: true, Reverse: true})
+
+		if result := srt.Compare("jan", "feb"); result != 1 {
+			t.Errorf("got %d, want 1", result)
+		}
+	})
+}
+
+func TestReadData(t *testing.T) {
+	t.Run("Read data", func(t *testing.T) {
+		input := strings.NewReader("line1\nline2\nline3")
+		expected := []string{"line1", "line2", "line3"}
+
+		result, err := utils.ReadData(input)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if !reflect.DeepEqual(result, expected) {
+			t.Errorf("got %v, want %v", result, expected)
+		}
+	})
+}
+
+func TestReadRecords(t *testing.T) {
+	t.Run("Read zero terminated records", func(t *testing.T) {
+		input := strings.NewReader("line1\nstill line1\x00line2\x00line3")
+		expected := []string{"line1\nstill line1", "line2", "line3"}
+
+		result, err := utils.ReadRecords(input, 0)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if !reflect.DeepEqual(result, expected) {
+			t.Errorf("got %v, want %v", result, expected)
+		}
+	})
+}
+
+func TestWriteData(t *testing.T) {
+	t.Run("Write data", func(t *testing.T) {
+		var output strings.Builder
+		data := []string{"line1", "line2", "line3"}
+
+		err := utils.WriteData(&output, data...)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		expected := "line1\nline2\nline3\n"
+
+		if output.String() != expected {
+			t.Errorf("got %q, want %q", output.String(), expected)
+		}
+	})
+}
+
+func TestTrimLeadingSpaces(t *testing.T) {
+	t.Run("Trim leading spaces", func(t *testing.T) {
+		input := "   \n\ttrim spaces  "
+		expected := "trim spaces  "
+
+		result := utils.TrimLeadingSpaces(input)
+		if result != expected {
+			t.Errorf("got %q, want %q", result, expected)
+		}
+	})
+}
 
 func TestParseMonth(t *testing.T) {
 	t.Run("Valid month", func(t *testing.T) {
@@ -728,11 +2062,42 @@ func TestParseMonth(t *testing.T) {
 	})
 }
 
+func TestNewCollator(t *testing.T) {
+	tests := []struct {
+		name        string
+		locale      string
+		hasCollator bool
+		hasErr      bool
+	}{
+		{name: "Empty locale", locale: ""},
+		{name: "C locale", locale: "C.UTF-8"},
+		{name: "POSIX locale", locale: "POSIX"},
+		{name: "POSIX format", locale: "ru_RU.UTF-8", hasCollator: true},
+		{name: "BCP 47 format", locale: "en-US", hasCollator: true},
+		{name: "Invalid locale", locale: "not a locale", hasErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collator, err := utils.NewCollator(tt.locale)
+
+			if (err != nil) != tt.hasErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.hasErr)
+				return
+			}
+
+			if (collator != nil) != tt.hasCollator {
+				t.Errorf("got collator %v, want %v", collator != nil, tt.hasCollator)
+			}
+		})
+	}
+}
+
 func TestParseNumericValue(t *testing.T) {
 	t.Run("With suffix", func(t *testing.T) {
 		input := "10K"
 		suffix := true
-		expected := 10000.0
+		expected := 10240.0
 
 		result, err := utils.ParseNumericValue(input, suffix)
 		if err != nil {
@@ -759,6 +2124,21 @@ func TestParseNumericValue(t *testing.T) {
 		}
 	})
 
+	t.Run("Negative with thousands separators", func(t *testing.T) {
+		input := "-1,234,567.5"
+		suffix := false
+		expected := -1234567.5
+
+		result, err := utils.ParseNumericValue(input, suffix)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if result != expected {
+			t.Errorf("got %f, want %f", result, expected)
+		}
+	})
+
 	t.Run("Invalid value", func(t *testing.T) {
 		input := "abc"
 		suffix := false
@@ -783,3 +2163,221 @@ func TestRemoveDuplicates(t *testing.T) {
 		}
 	})
 }
+
+func TestParseHumanSize(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		si            bool
+		expected      float64
+		expectedOrder int
+		hasErr        bool
+	}{
+		{name: "No suffix", input: "512", expected: 512},
+		{name: "Bytes", input: "512b", expected: 512},
+		{name: "Kibibytes", input: "2Ki", expected: 2048, expectedOrder: 1},
+		{name: "Megabytes", input: "1.5MB", expected: 1.5 * 1024 * 1024, expectedOrder: 2},
+		{name: "Petabytes", input: "1P", expected: math.Pow(1024, 5), expectedOrder: 5},
+		{name: "Negative", input: "-3G", expected: -3 * math.Pow(1024, 3), expectedOrder: 3},
+		{name: "SI", input: "2k", si: true, expected: 2000, expectedOrder: 1},
+		{name: "Invalid value", input: "K", hasErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, order, err := utils.ParseHumanSize(tt.input, tt.si)
+
+			if (err != nil) != tt.hasErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.hasErr)
+				return
+			}
+
+			if tt.hasErr {
+				return
+			}
+
+			if result != tt.expected || order != tt.expectedOrder {
+				t.Errorf("got %f, %d, want %f, %d", result, order, tt.expected, tt.expectedOrder)
+			}
+		})
+	}
+}
+
+func TestCompareHumanSizes(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		si       bool
+		expected int
+	}{
+		{a: "1000K", b: "1M", expected: -1},
+		{a: "1024K", b: "1M", expected: -1},
+		{a: "-1M", b: "-1000K", expected: -1},
+		{a: "2K", b: "2Ki", expected: 0},
+		{a: "0", b: "0K", expected: 0},
+		{a: "-1", b: "0", expected: -1},
+		{a: "2000", b: "1K", expected: -1},
+		{a: "1024", b: "1K", expected: -1},
+		{a: "1010", b: "1K", si: true, expected: -1},
+		{a: "1k", b: "1Ki", si: true, expected: 0},
+		{a: "1.5M", b: "1500K", si: true, expected: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			result, err := utils.CompareHumanSizes(tt.a, tt.b, tt.si)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if result != tt.expected {
+				t.Errorf("got %d, want %d", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		layout   string
+		expected time.Time
+		hasErr   bool
+	}{
+		{
+			name:     "RFC3339",
+			input:    " 2023-05-01T10:00:00+03:00 ",
+			expected: time.Date(2023, 5, 1, 7, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "RFC1123 with numeric zone",
+			input:    "Mon, 01 May 2023 07:00:00 +0000",
+			expected: time.Date(2023, 5, 1, 7, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Unix epoch",
+			input:    "1682924400.25",
+			expected: time.Date(2023, 5, 1, 7, 0, 0, 250000000, time.UTC),
+		},
+		{
+			name:     "Layout",
+			input:    "01/05/23",
+			layout:   "02/01/06",
+			expected: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{name: "Invalid", input: "yesterday", hasErr: true},
+		{name: "Layout mismatch", input: "2023-05-01", layout: "02/01/06", hasErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := utils.ParseTime(tt.input, tt.layout)
+
+			if (err != nil) != tt.hasErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.hasErr)
+				return
+			}
+
+			if !tt.hasErr && !result.Equal(tt.expected) {
+				t.Errorf("got %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStrftimeLayout(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		hasErr   bool
+	}{
+		{input: "%Y-%m-%d %H:%M:%S", expected: "2006-01-02 15:04:05"},
+		{input: "%d %b %y, %I:%M %p %%", expected: "02 Jan 06, 03:04 PM %"},
+		{input: "%F %T %z", expected: "2006-01-02 15:04:05 -0700"},
+		{input: "%Q", hasErr: true},
+		{input: "%Y%", hasErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := utils.StrftimeLayout(tt.input)
+
+			if (err != nil) != tt.hasErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.hasErr)
+				return
+			}
+
+			if result != tt.expected {
+				t.Errorf("got %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseGeneralNumericValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected float64
+		hasErr   bool
+	}{
+		{name: "Integer", input: "  42abc", expected: 42},
+		{name: "Signed", input: "+7", expected: 7},
+		{name: "Negative fraction", input: "-2.5", expected: -2.5},
+		{name: "Exponent", input: "1e3", expected: 1000},
+		{name: "Hex", input: "0x1A", expected: 26},
+		{name: "Infinity", input: "-Infinity", expected: math.Inf(-1)},
+		{name: "Overflow", input: "1e400", expected: math.Inf(1)},
+		{name: "Invalid value", input: "abc", hasErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := utils.ParseGeneralNumericValue(tt.input)
+
+			if (err != nil) != tt.hasErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.hasErr)
+				return
+			}
+
+			if !tt.hasErr && result != tt.expected {
+				t.Errorf("got %f, want %f", result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("NaN", func(t *testing.T) {
+		result, err := utils.ParseGeneralNumericValue("nan")
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if !math.IsNaN(result) {
+			t.Errorf("got %f, want NaN", result)
+		}
+	})
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{a: "1.9", b: "1.10.2", expected: -1},
+		{a: "1.10", b: "1.9.1", expected: 1},
+		{a: "1.02", b: "1.2", expected: 0},
+		{a: "1.2~rc1", b: "1.2", expected: -1},
+		{a: "1.2", b: "1.2-rc1", expected: -1},
+		{a: "1.2a", b: "1.2-", expected: -1},
+		{a: "file10.txt", b: "file9.txt", expected: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			result := utils.CompareVersions(tt.a, tt.b)
+			if result != tt.expected {
+				t.Errorf("got %d, want %d", result, tt.expected)
+			}
+		})
+	}
+}
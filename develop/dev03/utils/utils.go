@@ -2,20 +2,35 @@ package utils
 
 import (
 	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+	"hash/fnv"
 	"io"
+	"math"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 )
 
 // ReadData принимает на вход reader, возвращает слайс прочитанных строк
 func ReadData(reader io.Reader) ([]string, error) {
+	return ReadRecords(reader, '\n')
+}
+
+// ReadRecords принимает на вход reader и разделитель записей, возвращает слайс прочитанных записей без разделителей
+func ReadRecords(reader io.Reader, delimiter byte) ([]string, error) {
 	var lines []string
 
-	scanner := bufio.NewScanner(reader)
+	scanner := NewScanner(reader, delimiter)
 
 	for scanner.Scan() {
 		lines = append(lines, scanner.Text())
@@ -28,6 +43,32 @@ func ReadData(reader io.Reader) ([]string, error) {
 	return lines, nil
 }
 
+// NewScanner принимает на вход reader и разделитель записей, возвращает bufio.Scanner, читающий reader по записям
+func NewScanner(reader io.Reader, delimiter byte) *bufio.Scanner {
+	scanner := bufio.NewScanner(reader)
+
+	// для перевода строки используется стандартное разбиение, которое также убирает \r в конце строки
+	if delimiter != '\n' {
+		scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+			if atEOF && len(data) == 0 {
+				return 0, nil, nil
+			}
+
+			if i := bytes.IndexByte(data, delimiter); i >= 0 {
+				return i + 1, data[:i], nil
+			}
+
+			if atEOF {
+				return len(data), data, nil
+			}
+
+			return 0, nil, nil
+		})
+	}
+
+	return scanner
+}
+
 // WriteData принимает на вход writer и слайс данных любого типа data для записи, записывает построчно data в writer
 func WriteData[T any](writer io.Writer, data ...T) error {
 	for _, line := range data {
@@ -40,6 +81,73 @@ func WriteData[T any](writer io.Writer, data ...T) error {
 	return nil
 }
 
+// WriteRecords принимает на вход writer, разделитель записей и слайс записей, записывает в writer каждую запись,
+// завершая её разделителем
+func WriteRecords(writer io.Writer, delimiter byte, records ...string) error {
+	for _, record := range records {
+		_, err := io.WriteString(writer, record)
+		if err != nil {
+			return err
+		}
+
+		_, err = writer.Write([]byte{delimiter})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AtomicFile файл, запись в который производится во временный файл в той же директории, а на место целевого файла
+// он атомарно переименовывается только при вызове Commit. Это позволяет безопасно перезаписывать файл, который
+// одновременно является источником данных
+type AtomicFile struct {
+	*os.File
+	path string
+}
+
+// CreateAtomic создает временный файл для последующей атомарной замены файла path
+func CreateAtomic(path string) (*AtomicFile, error) {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+
+	// временный файл создается с правами 0600, поэтому выставляем права существующего файла или 0644 для нового
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	err = file.Chmod(mode)
+	if err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return nil, err
+	}
+
+	return &AtomicFile{File: file, path: path}, nil
+}
+
+// Commit закрывает временный файл и переименовывает его в целевой
+func (af *AtomicFile) Commit() error {
+	err := af.File.Close()
+	if err != nil {
+		_ = os.Remove(af.File.Name())
+		return err
+	}
+
+	return os.Rename(af.File.Name(), af.path)
+}
+
+// Abort закрывает и удаляет временный файл, целевой файл остается без изменений
+func (af *AtomicFile) Abort() error {
+	_ = af.File.Close()
+
+	return os.Remove(af.File.Name())
+}
+
 // TrimLeadingSpaces убирает у s слева любые пробельные символы, возвращает мутированную строку
 func TrimLeadingSpaces(s string) string {
 	return strings.TrimLeftFunc(s, func(r rune) bool {
@@ -47,110 +155,405 @@ func TrimLeadingSpaces(s string) string {
 	})
 }
 
+// monthPrefixes сопоставляет первые три буквы названия месяца в верхнем регистре его индексу, поддерживаются
+// английские и русские названия (в том числе в родительном падеже)
+var monthPrefixes = map[string]int{
+	"JAN": 0, "FEB": 1, "MAR": 2, "APR": 3, "MAY": 4, "JUN": 5,
+	"JUL": 6, "AUG": 7, "SEP": 8, "OCT": 9, "NOV": 10, "DEC": 11,
+	"ЯНВ": 0, "ФЕВ": 1, "МАР": 2, "АПР": 3, "МАЙ": 4, "МАЯ": 4, "ИЮН": 5,
+	"ИЮЛ": 6, "АВГ": 7, "СЕН": 8, "ОКТ": 9, "НОЯ": 10, "ДЕК": 11,
+}
+
 // ParseMonth принимает на вхож строку, возвращает индекс месяца, если, убрав первые пробельные символы, первые три
-// символа окажутся началом названия месяца на английском, иначе -1 и ошибку
+// символа окажутся началом названия месяца на английском или русском, иначе -1 и ошибку
 func ParseMonth(word string) (int, error) {
 	var errParse = errors.New("month cannot be parsed")
 	// убираем первые пробельные символы
-	word = TrimLeadingSpaces(word)
+	runes := []rune(TrimLeadingSpaces(word))
 
 	// если символов в оставшейся строке меньше 3, то этого количества символов недостаточно для однозначного
 	// определения месяца
-	if len(word) < 3 {
+	if len(runes) < 3 {
 		return -1, errParse
 	}
 
 	// первые три символа в верхнем регистре
-	firstThreeLetters := strings.ToTitle(word[:3])
-
-	switch firstThreeLetters {
-	case "JAN":
-		return 0, nil
-	case "FEB":
-		return 1, nil
-	case "MAR":
-		return 2, nil
-	case "APR":
-		return 3, nil
-	case "MAY":
-		return 4, nil
-	case "JUN":
-		return 5, nil
-	case "JUL":
-		return 6, nil
-	case "AUG":
-		return 7, nil
-	case "SEP":
-		return 8, nil
-	case "OCT":
-		return 9, nil
-	case "NOV":
-		return 10, nil
-	case "DEC":
-		return 11, nil
-	default:
+	firstThreeLetters := strings.ToTitle(string(runes[:3]))
+
+	index, ok := monthPrefixes[firstThreeLetters]
+	if !ok {
 		return -1, errParse
 	}
+
+	return index, nil
+}
+
+// timeLayouts форматы даты и времени, которые ParseTime пробует по очереди, если формат не задан
+var timeLayouts = []string{time.RFC3339Nano, time.RFC1123Z, time.RFC1123}
+
+// epochRegex регулярное выражение для времени в формате Unix epoch (секунды, возможно с дробной частью)
+var epochRegex = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+
+// ParseTime принимает на вход строку и формат даты и времени в нотации Go (time.Layout), возвращает время, разобранное
+// из строки без пробельных символов по краям. Если формат пустой, определяет его автоматически: RFC 3339, RFC 1123
+// (в том числе с числовым часовым поясом) или Unix epoch
+func ParseTime(word, layout string) (time.Time, error) {
+	word = strings.TrimSpace(word)
+
+	if layout != "" {
+		return time.Parse(layout, word)
+	}
+
+	for _, layout := range timeLayouts {
+		if result, err := time.Parse(layout, word); err == nil {
+			return result, nil
+		}
+	}
+
+	if epochRegex.MatchString(word) {
+		seconds, err := strconv.ParseFloat(word, 64)
+		if err == nil {
+			whole, fraction := math.Modf(seconds)
+
+			return time.Unix(int64(whole), int64(fraction*1e9)).UTC(), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("time cannot be parsed: %q", word)
+}
+
+// strftimeDirectives сопоставляет директивам strftime элементы формата даты и времени Go
+var strftimeDirectives = map[byte]string{
+	'Y': "2006", 'y': "06", 'm': "01", 'd': "02", 'e': "_2", 'j': "002",
+	'H': "15", 'I': "03", 'M': "04", 'S': "05", 'p': "PM",
+	'b': "Jan", 'h': "Jan", 'B': "January", 'a': "Mon", 'A': "Monday",
+	'z': "-0700", 'Z': "MST", 'F': "2006-01-02", 'T': "15:04:05", '%': "%",
+}
+
+// StrftimeLayout принимает на вход формат даты и времени strftime (например, %Y-%m-%d %H:%M:%S), возвращает
+// соответствующий формат в нотации Go или ошибку, если в формате есть неподдерживаемая директива
+func StrftimeLayout(layout string) (string, error) {
+	var builder strings.Builder
+
+	for i := 0; i < len(layout); i++ {
+		if layout[i] != '%' {
+			builder.WriteByte(layout[i])
+			continue
+		}
+
+		if i+1 == len(layout) {
+			return "", fmt.Errorf("incomplete strftime directive in %q", layout)
+		}
+
+		i++
+
+		directive, ok := strftimeDirectives[layout[i]]
+		if !ok {
+			return "", fmt.Errorf("unsupported strftime directive %%%c in %q", layout[i], layout)
+		}
+
+		builder.WriteString(directive)
+	}
+
+	return builder.String(), nil
+}
+
+// LocaleFromEnv возвращает локаль для сравнения строк из переменных окружения LC_ALL, LC_COLLATE, LANG (в порядке
+// приоритета), пустую строку, если ни одна из них не задана
+func LocaleFromEnv() string {
+	for _, name := range []string{"LC_ALL", "LC_COLLATE", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// NewCollator принимает на вход локаль в формате POSIX (ru_RU.UTF-8) или BCP 47 (ru-RU), возвращает объект для
+// сравнения строк по правилам Unicode Collation Algorithm для этой локали, nil для локалей C и POSIX или пустой
+// строки (побайтовое сравнение), ошибку, если локаль не удалось распознать
+func NewCollator(locale string) (*collate.Collator, error) {
+	// убираем кодировку и модификатор локали
+	if i := strings.IndexAny(locale, ".@"); i != -1 {
+		locale = locale[:i]
+	}
+
+	if locale == "" || locale == "C" || locale == "POSIX" {
+		return nil, nil
+	}
+
+	tag, err := language.Parse(strings.ReplaceAll(locale, "_", "-"))
+	if err != nil {
+		return nil, err
+	}
+
+	return collate.New(tag), nil
+}
+
+// ReadSeed читает из reader 8 байт и возвращает их как зерно для случайной сортировки
+func ReadSeed(reader io.Reader) (uint64, error) {
+	var buf [8]byte
+
+	_, err := io.ReadFull(reader, buf[:])
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.LittleEndian.Uint64(buf[:]), nil
 }
 
+// HashKey возвращает хэш FNV-1a ключа key, зависящий от зерна seed: одинаковые ключи при одном зерне всегда дают
+// одинаковый хэш, а порядок хэшей разных ключей случаен
+func HashKey(seed uint64, key string) uint64 {
+	hash := fnv.New64a()
+
+	_ = binary.Write(hash, binary.LittleEndian, seed)
+	_, _ = io.WriteString(hash, key)
+
+	return hash.Sum64()
+}
+
+// DictionaryOrder убирает из строки все символы, кроме пробельных, букв и цифр (опция -d)
+func DictionaryOrder(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+
+		return -1
+	}, s)
+}
+
+// IgnoreNonPrinting убирает из строки все непечатаемые символы (опция -i)
+func IgnoreNonPrinting(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsPrint(r) {
+			return r
+		}
+
+		return -1
+	}, s)
+}
+
+// numericRegex регулярное выражение соответствия числу для опции -n: необязательный минус, целая часть (в том числе
+// с разделением разрядов запятыми) и необязательная дробная часть
+var numericRegex = regexp.MustCompile(`^-?(\d{1,3}(,\d{3})+|\d+)(\.\d+)?`)
+
+// humanSuffixRegex регулярное выражение соответствия суффиксу размера для опции -h: K, M, G, T, P, E, Z, Y (в любом
+// регистре), необязательное i (IEC, например Ki) и необязательное B (байты), либо только B
+var humanSuffixRegex = regexp.MustCompile(`^([KkMmGgTtPpEeZzYy][Ii]?[Bb]?|[Bb])`)
+
+// humanSuffixes суффиксы размеров в порядке возрастания
+const humanSuffixes = "KMGTPEZY"
+
+// generalNumericRegex регулярное выражение соответствия числу для опции -g: десятичная или шестнадцатеричная запись
+// с необязательной экспонентой, бесконечность или NaN
+var generalNumericRegex = regexp.MustCompile(
+	`^[-+]?(0[xX]([0-9a-fA-F]+\.?[0-9a-fA-F]*|\.[0-9a-fA-F]+)([pP][-+]?\d+)?|(\d+\.?\d*|\.\d+)([eE][-+]?\d+)?|` +
+		`[iI][nN][fF]([iI][nN][iI][tT][yY])?|[nN][aA][nN])`,
+)
+
 // ParseNumericValue принимает на вход строку и флаг об использовании числового суффикса, возвращает распаршенное число
-// типа float64, если убрав первые пробельные символы, начало строки будет соответсвовать регулярному выражению
-// ^\d+(\.\d+)?[KkMmGgTt]?, иначе -1 и ошибку
+// типа float64, если, убрав первые пробельные символы, начало строки будет соответсвовать регулярному выражению
+// ^-?(\d{1,3}(,\d{3})+|\d+)(\.\d+)?, иначе -1 и ошибку. С суффиксом число умножается на степень 1024 в соответствии
+// с суффиксом размера (см. ParseHumanSize)
 func ParseNumericValue(word string, useSuffix bool) (float64, error) {
 	var errParse = errors.New("number cannot be parsed")
+
+	if useSuffix {
+		num, _, err := ParseHumanSize(word, false)
+		if err != nil {
+			return -1, errParse
+		}
+
+		return num, nil
+	}
+
 	// убираем первые пробельные символы
 	word = TrimLeadingSpaces(word)
 
-	// регулярное выражение соответсвия числу, в том числе с числовым суффиксом
-	pattern := `^\d+(\.\d+)?[KkMmGgTt]?`
-	// компиляция регулярного выражения
-	regex, err := regexp.Compile(pattern)
+	// поиск числа в строке по регулярному выражению
+	word = numericRegex.FindString(word)
 
-	if err != nil {
-		return -1, err
+	// если число не было найдено возвращает -1 и ошибку
+	if len(word) == 0 {
+		return -1, errParse
 	}
 
+	// убираем разделители разрядов
+	return strconv.ParseFloat(strings.ReplaceAll(word, ",", ""), 64)
+}
+
+// ParseHumanSize принимает на вход строку с размером в человекочитаемом формате (например, 1.5K, 10Ki, 2MB, -3G) и
+// флаг использования десятичных единиц, возвращает значение размера, порядок суффикса (0 - без суффикса, 1 - K,
+// 2 - M и так далее) или -1, -1 и ошибку. Суффиксы означают степени 1024, при si - степени 1000
+func ParseHumanSize(word string, si bool) (float64, int, error) {
+	var errParse = errors.New("number cannot be parsed")
+	// убираем первые пробельные символы
+	word = TrimLeadingSpaces(word)
+
 	// поиск числа в строке по регулярному выражению
-	word = regex.FindString(word)
+	number := numericRegex.FindString(word)
+
+	if len(number) == 0 {
+		return -1, -1, errParse
+	}
+
+	num, err := strconv.ParseFloat(strings.ReplaceAll(number, ",", ""), 64)
+	if err != nil {
+		return -1, -1, err
+	}
+
+	// поиск суффикса сразу после числа, B без множителя означает байты
+	suffix := humanSuffixRegex.FindString(word[len(number):])
+	order := 0
+
+	if suffix != "" {
+		order = strings.IndexByte(humanSuffixes, byte(unicode.ToUpper(rune(suffix[0])))) + 1
+	}
+
+	base := 1024.0
+	if si {
+		base = 1000
+	}
+
+	return num * math.Pow(base, float64(order)), order, nil
+}
+
+// CompareHumanSizes сравнивает два размера в человекочитаемом формате как GNU sort -h: сначала по знаку, затем по
+// порядку суффикса (для отрицательных - в обратном порядке), затем по значению. Возвращает -1, 0 или 1 или ошибку,
+// если одну из строк не удалось распарсить
+func CompareHumanSizes(a, b string, si bool) (int, error) {
+	num1, order1, err := ParseHumanSize(a, si)
+	if err != nil {
+		return 0, err
+	}
+
+	num2, order2, err := ParseHumanSize(b, si)
+	if err != nil {
+		return 0, err
+	}
+
+	sign1, sign2 := cmp.Compare(num1, 0), cmp.Compare(num2, 0)
+
+	switch {
+	case sign1 != sign2:
+		return cmp.Compare(sign1, sign2), nil
+	case sign1 != 0 && order1 != order2:
+		return sign1 * cmp.Compare(order1, order2), nil
+	default:
+		return cmp.Compare(num1, num2), nil
+	}
+}
+
+// ParseGeneralNumericValue принимает на вход строку, возвращает распаршенное число типа float64, если, убрав первые
+// пробельные символы, начало строки окажется числом с плавающей точкой (в том числе со знаком, экспонентой,
+// в шестнадцатеричной записи, inf или nan), иначе -1 и ошибку. Переполнение ошибкой не считается
+func ParseGeneralNumericValue(word string) (float64, error) {
+	var errParse = errors.New("number cannot be parsed")
+	// убираем первые пробельные символы
+	word = TrimLeadingSpaces(word)
+
+	// поиск самого длинного префикса строки, являющегося числом
+	word = generalNumericRegex.FindString(word)
 
-	// если число не было найдено возвращает -1 и ошибку
 	if len(word) == 0 {
 		return -1, errParse
 	}
 
-	// если нужно было учитывать суффикс, а суффикса на самом деле нет добавляем заглушку _ в конец строки и парсим,
-	// иначе если суффикс не нужно учитывать, а он есть - убираем и парсим
-	if useSuffix {
-		if unicode.IsDigit(rune(word[len(word)-1])) {
-			word = word + "_"
+	// strconv.ParseFloat требует двоичную экспоненту у шестнадцатеричных чисел, поэтому добавляем нулевую
+	lowerWord := strings.ToLower(word)
+	if strings.Contains(lowerWord, "0x") && !strings.Contains(lowerWord, "p") {
+		word += "p0"
+	}
+
+	num, err := strconv.ParseFloat(word, 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		return -1, err
+	}
+
+	return num, nil
+}
+
+// CompareVersions сравнивает две строки как номера версий (натуральный порядок): нечисловые части сравниваются
+// посимвольно, причем буквы идут раньше остальных символов, а ~ - раньше всего, в том числе конца строки, числовые
+// части сравниваются как числа. Возвращает -1, 0 или 1
+func CompareVersions(a, b string) int {
+	// order возвращает вес символа строки s по индексу i для сравнения нечисловых частей
+	order := func(s string, i int) int {
+		if i >= len(s) {
+			return 0
 		}
 
-		suffix := strings.ToTitle(string(word[len(word)-1]))
+		c := s[i]
 
-		num, err := strconv.ParseFloat(word[:len(word)-1], 64)
-		if err != nil {
-			return 0, err
+		switch {
+		case isDigit(c):
+			return 0
+		case c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			return int(c)
+		case c == '~':
+			return -1
+		default:
+			return int(c) + 256
 		}
+	}
+
+	i, j := 0, 0
+
+	for i < len(a) || j < len(b) {
+		// сравнение нечисловых частей
+		for (i < len(a) && !isDigit(a[i])) || (j < len(b) && !isDigit(b[j])) {
+			ac, bc := order(a, i), order(b, j)
+			if ac != bc {
+				return cmp.Compare(ac, bc)
+			}
 
-		switch suffix {
-		case "K":
-			num *= 1000
-		case "M":
-			num *= 1000000
-		case "G":
-			num *= 1000000000
-		case "T":
-			num *= 1000000000000
+			i++
+			j++
 		}
 
-		return num, nil
-	} else {
-		if unicode.IsDigit(rune(word[len(word)-1])) {
-			return strconv.ParseFloat(word, 64)
+		// пропуск ведущих нулей
+		for i < len(a) && a[i] == '0' {
+			i++
+		}
+
+		for j < len(b) && b[j] == '0' {
+			j++
 		}
 
-		return strconv.ParseFloat(word[:len(word)-1], 64)
+		// сравнение числовых частей: более длинное число больше, при равной длине решает первая отличающаяся цифра
+		firstDiff := 0
+
+		for i < len(a) && isDigit(a[i]) && j < len(b) && isDigit(b[j]) {
+			if firstDiff == 0 {
+				firstDiff = cmp.Compare(a[i], b[j])
+			}
+
+			i++
+			j++
+		}
+
+		if i < len(a) && isDigit(a[i]) {
+			return 1
+		}
+
+		if j < len(b) && isDigit(b[j]) {
+			return -1
+		}
+
+		if firstDiff != 0 {
+			return firstDiff
+		}
 	}
+
+	return 0
+}
+
+// isDigit проверяет, является ли байт ASCII цифрой
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
 }
 
 // RemoveDuplicates удаляет дубликаты в слайсе строк с помощью мапы
@@ -0,0 +1,424 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"io"
+	"math"
+	"slices"
+)
+
+// indexMagic сигнатура файла индекса анаграмм
+const indexMagic = "ANAGIDX1"
+
+// indexHeaderSize размер заголовка файла индекса: сигнатура, размер хеш-таблицы, количество слов
+const indexHeaderSize = len(indexMagic) + 4 + 4
+
+var (
+	// ErrCorruptIndex ошибка, возвращаемая, если файл индекса поврежден или имеет неизвестный формат
+	ErrCorruptIndex = errors.New("corrupt anagram index")
+	// ErrIndexTooLarge ошибка, возвращаемая, если индекс не помещается в формат файла (смещения до 4 ГиБ)
+	ErrIndexTooLarge = errors.New("anagram index too large")
+	// ErrIndexClosed ошибка, возвращаемая при обращении к индексу, загруженному из файла, после его закрытия
+	ErrIndexClosed = errors.New("anagram index is closed")
+)
+
+// AnagramIndex индекс анаграмм словаря, сопоставляющий сигнатуре отсортированные уникальные слова в нижнем регистре.
+// Индекс строится один раз и отвечает на запросы за O(1), слова можно добавлять и удалять по одному. Индекс,
+// загруженный из файла, читает множества прямо из отображенного в память файла, а измененные множества хранит в памяти
+type AnagramIndex struct {
+	// sets множества, построенные или измененные в памяти, пустое множество означает удаленные слова
+	sets map[string][]string
+	// data содержимое файла индекса, nil - если индекс построен в памяти
+	data []byte
+	// table хеш-таблица файла индекса со смещениями множеств, 0 - пустая ячейка
+	table []byte
+	// count количество слов в индексе
+	count int
+	// normalizer объект приведения слов к нормализованному виду, nil - только нижний регистр
+	normalizer *Normalizer
+	// release функция освобождения data
+	release func() error
+	// closed признак закрытия индекса, загруженного из файла
+	closed bool
+}
+
+// NewAnagramIndex конструктор, строящий индекс анаграмм по словарю
+func NewAnagramIndex(words []string) *AnagramIndex {
+	return NewNormalizedAnagramIndex(words, nil)
+}
+
+// NewNormalizedAnagramIndex конструктор, строящий индекс анаграмм по словарю, слова которого приводятся к
+// нормализованному виду normalizer (nil - только нижний регистр)
+func NewNormalizedAnagramIndex(words []string, normalizer *Normalizer) *AnagramIndex {
+	ai := &AnagramIndex{sets: make(map[string][]string), normalizer: normalizer}
+	seen := make(map[string]struct{})
+
+	for _, word := range words {
+		lowerWord := normalizer.Normalize(word)
+
+		if _, exists := seen[lowerWord]; exists || lowerWord == "" {
+			continue
+		}
+
+		seen[lowerWord] = struct{}{}
+		signature := SortWord(lowerWord)
+		ai.sets[signature] = append(ai.sets[signature], lowerWord)
+	}
+
+	for _, set := range ai.sets {
+		slices.Sort(set)
+		ai.count += len(set)
+	}
+
+	return ai
+}
+
+// SetNormalizer метод, задающий объект приведения слов к нормализованному виду для запросов и изменений индекса.
+// Для индекса, загруженного из файла, он должен совпадать с тем, с которым индекс был построен
+func (ai *AnagramIndex) SetNormalizer(normalizer *Normalizer) {
+	ai.normalizer = normalizer
+}
+
+// Len метод, возвращающий количество слов в индексе
+func (ai *AnagramIndex) Len() int {
+	return ai.count
+}
+
+// set метод, возвращающий множество слов с сигнатурой signature. Результат не должен изменяться
+func (ai *AnagramIndex) set(signature string) []string {
+	if set, ok := ai.sets[signature]; ok {
+		return set
+	}
+
+	if ai.data == nil {
+		return nil
+	}
+
+	// поиск в хеш-таблице файла с линейным пробированием, количество проб ограничено размером таблицы на случай
+	// поврежденного файла
+	mask := uint64(len(ai.table)/4 - 1)
+	slot := indexHash(signature) & mask
+
+	for probe := uint64(0); probe <= mask; probe, slot = probe+1, (slot+1)&mask {
+		offset := binary.LittleEndian.Uint32(ai.table[slot*4:])
+		if offset == 0 {
+			return nil
+		}
+
+		// сигнатура записи сравнивается прямо в файле, слова декодируются только у совпавшей записи
+		recordSignature, err := readIndexSignature(ai.data, int(offset))
+		if err != nil {
+			return nil
+		}
+
+		if string(recordSignature) != signature {
+			continue
+		}
+
+		_, words, _, err := readIndexRecord(ai.data, int(offset))
+		if err != nil {
+			return nil
+		}
+
+		return words
+	}
+
+	return nil
+}
+
+// Lookup метод, возвращающий отсортированные анаграммы слова word из индекса, не включая само слово, или
+// ErrIndexClosed, если индекс уже закрыт
+func (ai *AnagramIndex) Lookup(word string) ([]string, error) {
+	if ai.closed {
+		return nil, ErrIndexClosed
+	}
+
+	lowerWord := ai.normalizer.Normalize(word)
+	set := ai.set(SortWord(lowerWord))
+
+	result := make([]string, 0, len(set))
+
+	for _, candidate := range set {
+		if candidate != lowerWord {
+			result = append(result, candidate)
+		}
+	}
+
+	return result, nil
+}
+
+// Contains метод, проверяющий наличие слова в индексе. Для закрытого индекса возвращает false
+func (ai *AnagramIndex) Contains(word string) bool {
+	lowerWord := ai.normalizer.Normalize(word)
+	_, found := slices.BinarySearch(ai.set(SortWord(lowerWord)), lowerWord)
+
+	return found
+}
+
+// Add метод, добавляющий слово в индекс, возвращает false, если слово уже есть, оно пустое после нормализации или
+// индекс закрыт
+func (ai *AnagramIndex) Add(word string) bool {
+	lowerWord := ai.normalizer.Normalize(word)
+	signature := SortWord(lowerWord)
+	set := ai.set(signature)
+
+	position, found := slices.BinarySearch(set, lowerWord)
+	if found || lowerWord == "" || ai.closed {
+		return false
+	}
+
+	// множество копируется, так как оно может находиться в отображенном в память файле
+	ai.sets[signature] = slices.Insert(slices.Clone(set), position, lowerWord)
+	ai.count++
+
+	return true
+}
+
+// Remove метод, удаляющий слово из индекса, возвращает false, если слова нет или индекс закрыт
+func (ai *AnagramIndex) Remove(word string) bool {
+	lowerWord := ai.normalizer.Normalize(word)
+	signature := SortWord(lowerWord)
+	set := ai.set(signature)
+
+	position, found := slices.BinarySearch(set, lowerWord)
+	if !found {
+		return false
+	}
+
+	ai.sets[signature] = slices.Delete(slices.Clone(set), position, position+1)
+	ai.count--
+
+	return true
+}
+
+// Range метод, вызывающий fn для каждого непустого множества индекса, пока fn возвращает true. Порядок обхода не
+// определен. Для закрытого индекса возвращает ErrIndexClosed
+func (ai *AnagramIndex) Range(fn func(signature string, words []string) bool) error {
+	if ai.closed {
+		return ErrIndexClosed
+	}
+
+	for signature, set := range ai.sets {
+		if len(set) > 0 && !fn(signature, set) {
+			return nil
+		}
+	}
+
+	if ai.data == nil {
+		return nil
+	}
+
+	for offset := indexHeaderSize + len(ai.table); offset < len(ai.data); {
+		signature, words, next, err := readIndexRecord(ai.data, offset)
+		if err != nil {
+			return err
+		}
+
+		// множества, измененные в памяти, уже обойдены
+		if _, ok := ai.sets[signature]; !ok && !fn(signature, words) {
+			return nil
+		}
+
+		offset = next
+	}
+
+	return nil
+}
+
+// WriteTo метод, записывающий индекс в writer в бинарном формате, пригодном для отображения в память: заголовок,
+// хеш-таблица смещений множеств с линейным пробированием и множества, упорядоченные по сигнатуре
+func (ai *AnagramIndex) WriteTo(writer io.Writer) (int64, error) {
+	sets := make(map[string][]string)
+
+	err := ai.Range(func(signature string, words []string) bool {
+		sets[signature] = words
+		return true
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	signatures := make([]string, 0, len(sets))
+	for signature := range sets {
+		signatures = append(signatures, signature)
+	}
+
+	slices.Sort(signatures)
+
+	// размер хеш-таблицы - степень двойки, не меньше удвоенного количества множеств
+	tableSize := 1
+	for tableSize < 2*len(signatures) {
+		tableSize *= 2
+	}
+
+	table := make([]byte, 4*tableSize)
+	records := make([]byte, 0)
+	offset := indexHeaderSize + len(table)
+	mask := uint64(tableSize - 1)
+
+	for _, signature := range signatures {
+		if offset+len(records) > math.MaxUint32 {
+			return 0, ErrIndexTooLarge
+		}
+
+		slot := indexHash(signature) & mask
+		for binary.LittleEndian.Uint32(table[slot*4:]) != 0 {
+			slot = (slot + 1) & mask
+		}
+
+		binary.LittleEndian.PutUint32(table[slot*4:], uint32(offset+len(records)))
+
+		records = appendIndexRecord(records, signature, sets[signature])
+	}
+
+	header := make([]byte, 0, indexHeaderSize)
+	header = append(header, indexMagic...)
+	header = binary.LittleEndian.AppendUint32(header, uint32(tableSize))
+	header = binary.LittleEndian.AppendUint32(header, uint32(ai.count))
+
+	bufferedWriter := bufio.NewWriter(writer)
+
+	var written int64
+
+	for _, part := range [][]byte{header, table, records} {
+		n, err := bufferedWriter.Write(part)
+		written += int64(n)
+
+		if err != nil {
+			return written, err
+		}
+	}
+
+	return written, bufferedWriter.Flush()
+}
+
+// NewAnagramIndexFromBytes конструктор, создающий индекс анаграмм по содержимому файла индекса без его копирования
+func NewAnagramIndexFromBytes(data []byte) (*AnagramIndex, error) {
+	if len(data) < indexHeaderSize || string(data[:len(indexMagic)]) != indexMagic {
+		return nil, ErrCorruptIndex
+	}
+
+	tableSize := int(binary.LittleEndian.Uint32(data[len(indexMagic):]))
+	count := int(binary.LittleEndian.Uint32(data[len(indexMagic)+4:]))
+
+	if tableSize == 0 || tableSize&(tableSize-1) != 0 || len(data) < indexHeaderSize+4*tableSize {
+		return nil, ErrCorruptIndex
+	}
+
+	return &AnagramIndex{
+		sets:  make(map[string][]string),
+		data:  data,
+		table: data[indexHeaderSize : indexHeaderSize+4*tableSize],
+		count: count,
+	}, nil
+}
+
+// LoadAnagramIndex конструктор, загружающий индекс анаграмм из файла, записанного WriteTo. Файл отображается в память
+// (там, где это поддерживается), поэтому загрузка не зависит от размера словаря. После использования индекс
+// необходимо закрыть методом Close
+func LoadAnagramIndex(path string) (*AnagramIndex, error) {
+	data, release, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ai, err := NewAnagramIndexFromBytes(data)
+	if err != nil {
+		_ = release()
+		return nil, err
+	}
+
+	ai.release = release
+
+	return ai, nil
+}
+
+// Close метод, освобождающий отображенный в память файл индекса. После закрытия Lookup и Range возвращают
+// ErrIndexClosed. Для индекса, построенного в памяти, ничего не делает
+func (ai *AnagramIndex) Close() error {
+	if ai.release == nil {
+		return nil
+	}
+
+	release := ai.release
+	ai.data, ai.table, ai.sets, ai.release = nil, nil, nil, nil
+	ai.closed = true
+
+	return release()
+}
+
+// indexHash возвращает хеш FNV-64a сигнатуры для хеш-таблицы файла индекса
+func indexHash(signature string) uint64 {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(signature))
+
+	return hash.Sum64()
+}
+
+// appendIndexRecord добавляет к buffer запись множества: длина и байты сигнатуры, количество слов, длина и байты
+// каждого слова (длины и количество в формате uvarint)
+func appendIndexRecord(buffer []byte, signature string, words []string) []byte {
+	buffer = binary.AppendUvarint(buffer, uint64(len(signature)))
+	buffer = append(buffer, signature...)
+	buffer = binary.AppendUvarint(buffer, uint64(len(words)))
+
+	for _, word := range words {
+		buffer = binary.AppendUvarint(buffer, uint64(len(word)))
+		buffer = append(buffer, word...)
+	}
+
+	return buffer
+}
+
+// readIndexSignature возвращает байты сигнатуры записи множества из data по смещению offset без копирования
+func readIndexSignature(data []byte, offset int) ([]byte, error) {
+	length, n := binary.Uvarint(data[min(offset, len(data)):])
+	if n <= 0 || length > uint64(len(data)-offset-n) {
+		return nil, ErrCorruptIndex
+	}
+
+	return data[offset+n : offset+n+int(length)], nil
+}
+
+// readIndexRecord читает запись множества из data по смещению offset, возвращает сигнатуру, слова и смещение
+// следующей записи
+func readIndexRecord(data []byte, offset int) (string, []string, int, error) {
+	// readString читает строку, предваренную длиной
+	readString := func() (string, error) {
+		length, n := binary.Uvarint(data[min(offset, len(data)):])
+		if n <= 0 || length > uint64(len(data)-offset-n) {
+			return "", ErrCorruptIndex
+		}
+
+		offset += n
+		text := string(data[offset : offset+int(length)])
+		offset += int(length)
+
+		return text, nil
+	}
+
+	signature, err := readString()
+	if err != nil {
+		return "", nil, 0, err
+	}
+
+	count, n := binary.Uvarint(data[min(offset, len(data)):])
+	if n <= 0 || count > uint64(len(data)-offset) {
+		return "", nil, 0, ErrCorruptIndex
+	}
+
+	offset += n
+	words := make([]string, count)
+
+	for i := range words {
+		words[i], err = readString()
+		if err != nil {
+			return "", nil, 0, err
+		}
+	}
+
+	return signature, words, offset, nil
+}
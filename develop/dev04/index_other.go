@@ -0,0 +1,16 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// mapFile читает файл в память целиком на платформах без поддержки отображения файлов в память, возвращает его
+// содержимое и функцию освобождения
+func mapFile(path string) ([]byte, func() error, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return data, func() error { return nil }, nil
+}
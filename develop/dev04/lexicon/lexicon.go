@@ -0,0 +1,200 @@
+// Package lexicon реализует поиск палиндромов и лестниц слов (кратчайших цепочек слов, соседние слова которых
+// отличаются одной буквой) по словарю, загруженному и нормализованному утилитой поиска анаграмм
+package lexicon
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"unicode"
+	"wb-level-2/develop/dev04/utils"
+)
+
+var (
+	// ErrUnknownWord ошибка, возвращаемая, если конечного слова лестницы нет в словаре
+	ErrUnknownWord = errors.New("word is not in the dictionary")
+	// ErrNoLadder ошибка, возвращаемая, если лестницы между словами не существует
+	ErrNoLadder = errors.New("no word ladder")
+)
+
+// Lexicon словарь для словесных игр
+type Lexicon struct {
+	words    []string
+	contains map[string]struct{}
+	fold     func(string) string
+
+	// индекс лестниц слов строится при первом поиске лестницы
+	bucketsOnce sync.Once
+	buckets     map[string][]string
+}
+
+// New конструктор для создания объекта структуры Lexicon. Принимает на вход слова в виде для сравнения и функцию
+// fold, приводящую к этому виду слова запросов, nil - strings.ToLower. Пустые и повторяющиеся слова пропускаются
+func New(words []string, fold func(string) string) *Lexicon {
+	if fold == nil {
+		fold = strings.ToLower
+	}
+
+	lexicon := &Lexicon{
+		words:    make([]string, 0, len(words)),
+		contains: make(map[string]struct{}, len(words)),
+		fold:     fold,
+	}
+
+	for _, word := range words {
+		if _, found := lexicon.contains[word]; word != "" && !found {
+			lexicon.words = append(lexicon.words, word)
+			lexicon.contains[word] = struct{}{}
+		}
+	}
+
+	return lexicon
+}
+
+// Len метод, возвращающий количество слов словаря
+func (l *Lexicon) Len() int {
+	return len(l.words)
+}
+
+// Contains метод, проверяющий наличие слова в словаре
+func (l *Lexicon) Contains(word string) bool {
+	_, exists := l.contains[l.fold(word)]
+	return exists
+}
+
+// isPalindrome проверяет, читается ли слово одинаково в обоих направлениях, графемные кластеры (символы вместе с
+// диакритическими знаками) переставляются целиком, как и при поиске анаграмм
+func isPalindrome(word string) bool {
+	letters := utils.Clusters(word)
+
+	for i, j := 0, len(letters)-1; i < j; i, j = i+1, j-1 {
+		if letters[i] != letters[j] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsPalindrome метод, проверяющий, является ли слово палиндромом после приведения к виду для сравнения
+func (l *Lexicon) IsPalindrome(word string) bool {
+	return isPalindrome(l.fold(word))
+}
+
+// IsPhrasePalindrome метод, проверяющий, является ли фраза палиндромом после приведения к виду для сравнения без
+// учета пробелов и знаков препинания, например, "А роза упала на лапу Азора"
+func (l *Lexicon) IsPhrasePalindrome(phrase string) bool {
+	letters := strings.Map(func(r rune) rune {
+		if unicode.In(r, unicode.L, unicode.M, unicode.Nd) {
+			return r
+		}
+
+		return -1
+	}, l.fold(phrase))
+
+	return letters != "" && isPalindrome(letters)
+}
+
+// Palindromes метод, возвращающий слова словаря, которые являются палиндромами и содержат не меньше minLength
+// символов, в порядке словаря
+func (l *Lexicon) Palindromes(minLength int) []string {
+	result := make([]string, 0)
+
+	for _, word := range l.words {
+		if len(utils.Clusters(word)) >= minLength && isPalindrome(word) {
+			result = append(result, word)
+		}
+	}
+
+	return result
+}
+
+// wildcards возвращает шаблоны слова, в каждом из которых один символ заменен подстановочным знаком: префикс и
+// суффикс разделяются нулевым байтом, который не встречается в словах
+func wildcards(word string) []string {
+	letters := utils.Clusters(word)
+	result := make([]string, len(letters))
+
+	for i := range letters {
+		result[i] = strings.Join(letters[:i], "") + "\x00" + strings.Join(letters[i+1:], "")
+	}
+
+	return result
+}
+
+// index метод, возвращающий индекс лестниц слов: в каждой корзине индекса находятся слова, совпадающие с шаблоном
+// корзины, то есть отличающиеся друг от друга одним символом в одной и той же позиции
+func (l *Lexicon) index() map[string][]string {
+	l.bucketsOnce.Do(func() {
+		l.buckets = make(map[string][]string)
+
+		for _, word := range l.words {
+			for _, pattern := range wildcards(word) {
+				l.buckets[pattern] = append(l.buckets[pattern], word)
+			}
+		}
+	})
+
+	return l.buckets
+}
+
+// Ladder метод, возвращающий кратчайшую лестницу слов от from до to: каждое следующее слово отличается от
+// предыдущего одним символом, все слова, кроме from, есть в словаре. Поиск в ширину ведется по индексу корзин
+// шаблонов, поэтому соседи слова находятся без перебора всего словаря. Возвращает ErrUnknownWord, если to нет в
+// словаре, ErrNoLadder - если лестницы не существует
+func (l *Lexicon) Ladder(from, to string) ([]string, error) {
+	from, to = l.fold(from), l.fold(to)
+
+	if _, exists := l.contains[to]; !exists {
+		return nil, ErrUnknownWord
+	}
+
+	if from == to {
+		return []string{from}, nil
+	}
+
+	buckets := l.index()
+
+	// previous - предыдущее слово лестницы для каждого посещенного слова
+	previous := map[string]string{from: ""}
+	queue := []string{from}
+
+	for len(queue) > 0 {
+		word := queue[0]
+		queue = queue[1:]
+
+		for _, pattern := range wildcards(word) {
+			for _, next := range buckets[pattern] {
+				if _, visited := previous[next]; visited {
+					continue
+				}
+
+				previous[next] = word
+
+				if next == to {
+					return buildLadder(previous, from, to), nil
+				}
+
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	return nil, ErrNoLadder
+}
+
+// buildLadder возвращает лестницу от from до to, восстановленную по предыдущим словам
+func buildLadder(previous map[string]string, from, to string) []string {
+	ladder := []string{to}
+
+	for word := to; word != from; {
+		word = previous[word]
+		ladder = append(ladder, word)
+	}
+
+	for i, j := 0, len(ladder)-1; i < j; i, j = i+1, j-1 {
+		ladder[i], ladder[j] = ladder[j], ladder[i]
+	}
+
+	return ladder
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// maxNearDistance наибольшее допустимое расстояние близких анаграмм (опция --distance): количество сигнатур с
+// удаленными символами растет как число сочетаний из длины слова по расстоянию
+const maxNearDistance = 3
+
+// nearClass множество точных анаграмм при поиске близких анаграмм
+type nearClass struct {
+	// clusters отсортированные графемные кластеры слов множества
+	clusters []string
+	// variants сигнатуры, получаемые удалением кластеров из clusters
+	variants []string
+	// cluster номер множества близких анаграмм, в которое попало множество, -1 - еще не распределено
+	cluster int
+	// distance расстояние от ключа множества близких анаграмм
+	distance int
+}
+
+// nearMember слово множества близких анаграмм с расстоянием от ключа
+type nearMember struct {
+	word     string
+	distance int
+}
+
+// deletionVariants возвращает без повторов сигнатуры, получаемые удалением из отсортированных кластеров clusters
+// от 0 до k кластеров. Если расстояние между двумя словами не больше k, у них есть общая сигнатура - пересечение
+// мультимножеств их кластеров, поэтому по этим сигнатурам находятся все кандидаты в близкие анаграммы
+func deletionVariants(clusters []string, k int) []string {
+	variants := []string{strings.Join(clusters, "")}
+	seen := map[string]bool{variants[0]: true}
+
+	var deleteFrom func(current []string, start, left int)
+
+	deleteFrom = func(current []string, start, left int) {
+		if left == 0 {
+			return
+		}
+
+		for i := start; i < len(current); i++ {
+			// удаление одинаковых соседних кластеров дает одну и ту же сигнатуру
+			if i > start && current[i] == current[i-1] {
+				continue
+			}
+
+			next := slices.Delete(slices.Clone(current), i, i+1)
+
+			variant := strings.Join(next, "")
+			if !seen[variant] {
+				seen[variant] = true
+				variants = append(variants, variant)
+			}
+
+			deleteFrom(next, i, left-1)
+		}
+	}
+
+	deleteFrom(clusters, 0, k)
+
+	return variants
+}
+
+// clusterDistance возвращает расстояние между мультимножествами отсортированных кластеров: наименьшее количество
+// вставок, удалений и замен кластеров, равное большему из количеств кластеров, которых нет в другом мультимножестве
+func clusterDistance(clusters1, clusters2 []string) int {
+	onlyIn1, onlyIn2 := 0, 0
+	i, j := 0, 0
+
+	for i < len(clusters1) && j < len(clusters2) {
+		switch cmp.Compare(clusters1[i], clusters2[j]) {
+		case -1:
+			onlyIn1++
+			i++
+		case 1:
+			onlyIn2++
+			j++
+		default:
+			i++
+			j++
+		}
+	}
+
+	onlyIn1 += len(clusters1) - i
+	onlyIn2 += len(clusters2) - j
+
+	return max(onlyIn1, onlyIn2)
+}
+
+// FindNearAnagramSets аналог FindOrderedAnagramSets, который объединяет в одно множество слова, мультимножества
+// символов которых отличаются не больше чем на maxDistance вставок, удалений или замен символов, например, анаграммы
+// с опечатками. Множества строятся жадно в порядке первого появления: первое еще не распределенное слово становится
+// ключом, к нему присоединяются все не распределенные слова на расстоянии не больше maxDistance от него. Кандидаты
+// находятся по индексу сигнатур с удаленными символами, расстояние каждого слова от ключа записывается в Distances
+func FindNearAnagramSets(words []string, normalizer *Normalizer, maxDistance int) []AnagramSet {
+	maxDistance = max(maxDistance, 0)
+	groups, seen := groupAnagrams(words, normalizer)
+
+	// индекс сигнатур с удаленными кластерами, в значении - номера множеств точных анаграмм
+	classes := make([]nearClass, len(groups))
+	classIndexes := make(map[*AnagramSet]int, len(groups))
+	index := make(map[string][]int)
+
+	for i, group := range groups {
+		clusters := sortedClusters(group.Words[0])
+		classes[i] = nearClass{
+			clusters: clusters,
+			variants: deletionVariants(clusters, maxDistance),
+			cluster:  -1,
+		}
+		classIndexes[group] = i
+
+		for _, variant := range classes[i].variants {
+			index[variant] = append(index[variant], i)
+		}
+	}
+
+	// жадное распределение множеств точных анаграмм по множествам близких анаграмм, centers - номера множеств
+	// точных анаграмм, содержащих ключи
+	var centers []int
+
+	for i := range classes {
+		if classes[i].cluster != -1 {
+			continue
+		}
+
+		cluster := len(centers)
+		centers = append(centers, i)
+		classes[i].cluster = cluster
+
+		for _, variant := range classes[i].variants {
+			for _, j := range index[variant] {
+				if classes[j].cluster != -1 {
+					continue
+				}
+
+				distance := clusterDistance(classes[i].clusters, classes[j].clusters)
+				if distance <= maxDistance {
+					classes[j].cluster = cluster
+					classes[j].distance = distance
+				}
+			}
+		}
+	}
+
+	members := make([][]nearMember, len(centers))
+
+	for i, group := range groups {
+		for _, word := range group.Words {
+			members[classes[i].cluster] = append(members[classes[i].cluster], nearMember{
+				word:     word,
+				distance: classes[i].distance,
+			})
+		}
+	}
+
+	sets := make([]AnagramSet, len(centers))
+	for cluster, center := range centers {
+		sets[cluster] = AnagramSet{Key: groups[center].Key, FirstIndex: groups[center].FirstIndex}
+	}
+
+	// исходные формы слов в порядке их появления во входных данных
+	for _, word := range words {
+		group, exists := seen[normalizer.Normalize(word)]
+		if !exists {
+			continue
+		}
+
+		set := &sets[classes[classIndexes[group]].cluster]
+		if !slices.Contains(set.Forms, word) {
+			set.Forms = append(set.Forms, word)
+		}
+	}
+
+	// в результат попадают множества, в которых больше 1 слова, слова множества сортируются
+	result := make([]AnagramSet, 0)
+
+	for cluster, set := range sets {
+		if len(members[cluster]) < 2 {
+			continue
+		}
+
+		slices.SortFunc(members[cluster], func(member1, member2 nearMember) int {
+			return cmp.Compare(member1.word, member2.word)
+		})
+
+		for _, member := range members[cluster] {
+			set.Words = append(set.Words, member.word)
+			set.Distances = append(set.Distances, member.distance)
+		}
+
+		result = append(result, set)
+	}
+
+	return result
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+	"golang.org/x/text/unicode/norm"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// ErrUnknownForm ошибка, возвращаемая при неизвестной форме нормализации Unicode
+var ErrUnknownForm = errors.New("unknown normalization form, expected nfc or nfd")
+
+// NormalizeOptions опции приведения слов к виду, в котором они сравниваются при поиске анаграмм
+type NormalizeOptions struct {
+	// Form форма нормализации Unicode: "nfc", "nfd" или пустая строка - без нормализации
+	Form string
+	// FoldDiacritics убирать диакритические знаки (ё - е, é - e), кроме краткой в й, которая в русском языке
+	// образует отдельную букву
+	FoldDiacritics bool
+	// Locale язык (тег BCP 47) для приведения к нижнему регистру, например tr для турецкой I - ı, пустая строка -
+	// правила strings.ToLower
+	Locale string
+	// IgnorePunctuation не учитывать знаки препинания, пробелы и прочие символы, кроме букв, цифр и диакритических
+	// знаков
+	IgnorePunctuation bool
+}
+
+// Normalizer объект для приведения слов к нормализованному виду, безопасный для одновременного использования из
+// нескольких горутин. Нулевой указатель *Normalizer приводит слова только к нижнему регистру, как strings.ToLower
+type Normalizer struct {
+	options NormalizeOptions
+	form    norm.Form
+	// casers пул объектов приведения к нижнему регистру по правилам языка, nil - правила strings.ToLower.
+	// cases.Caser хранит состояние и не может использоваться одновременно из нескольких горутин
+	casers *sync.Pool
+}
+
+// NewNormalizer конструктор, создающий Normalizer по опциям, возвращает ошибку, если форма нормализации или язык
+// неизвестны
+func NewNormalizer(options NormalizeOptions) (*Normalizer, error) {
+	n := &Normalizer{options: options}
+
+	switch strings.ToLower(options.Form) {
+	case "":
+	case "nfc":
+		n.form = norm.NFC
+	case "nfd":
+		n.form = norm.NFD
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownForm, options.Form)
+	}
+
+	if options.Locale != "" {
+		tag, err := language.Parse(options.Locale)
+		if err != nil {
+			return nil, err
+		}
+
+		n.casers = &sync.Pool{
+			New: func() any {
+				caser := cases.Lower(tag)
+				return &caser
+			},
+		}
+	}
+
+	return n, nil
+}
+
+// Normalize метод, возвращающий слово, приведенное к нижнему регистру с учетом опций: сначала слово приводится к
+// нижнему регистру по правилам языка, затем убираются диакритические знаки (иначе точка разложенной турецкой İ
+// терялась бы до приведения к i), знаки препинания и применяется форма нормализации
+func (n *Normalizer) Normalize(word string) string {
+	if n == nil {
+		return strings.ToLower(word)
+	}
+
+	if n.casers != nil {
+		caser := n.casers.Get().(*cases.Caser)
+		word = caser.String(word)
+		n.casers.Put(caser)
+	} else {
+		word = strings.ToLower(word)
+	}
+
+	if n.options.FoldDiacritics {
+		word = foldDiacritics(word)
+	}
+
+	if n.options.IgnorePunctuation {
+		word = strings.Map(func(r rune) rune {
+			if unicode.In(r, unicode.L, unicode.M, unicode.N) {
+				return r
+			}
+
+			return -1
+		}, word)
+	}
+
+	if n.options.Form != "" {
+		word = n.form.String(word)
+	}
+
+	return word
+}
+
+// foldDiacritics возвращает слово без диакритических знаков: слово раскладывается (NFD), из него убираются
+// несамостоятельные знаки, кроме краткой после и (й), после чего слово снова собирается (NFC)
+func foldDiacritics(word string) string {
+	var builder strings.Builder
+
+	prev := rune(-1)
+
+	for _, r := range norm.NFD.String(word) {
+		if unicode.Is(unicode.Mn, r) && !(r == '\u0306' && (prev == 'и' || prev == 'И')) {
+			continue
+		}
+
+		builder.WriteRune(r)
+		prev = r
+	}
+
+	return norm.NFC.String(builder.String())
+}
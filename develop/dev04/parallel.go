@@ -0,0 +1,319 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"io"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// parallelBatchSize количество слов, передаваемых между горутинами за раз, чтобы накладные расходы на каналы не
+// превышали выигрыш от параллельной обработки
+const parallelBatchSize = 1024
+
+// sequencedWord слово входных данных с глобальным порядковым номером
+type sequencedWord struct {
+	index     int
+	word      string
+	lowerWord string
+	hash      uint64
+}
+
+// sequencedForm исходная форма слова с порядковым номером её первого появления
+type sequencedForm struct {
+	index int
+	word  string
+}
+
+// shardGroup множество анаграмм внутри шарда. Слова шарда приходят не по порядку, поэтому для каждой формы
+// хранится наименьший встреченный номер, ключ и порядок форм определяются при слиянии
+type shardGroup struct {
+	words []string
+	forms []sequencedForm
+}
+
+// addForm метод, добавляющий исходную форму слова в множество или уменьшающий номер уже добавленной формы
+func (sg *shardGroup) addForm(index int, word string) {
+	i := slices.IndexFunc(sg.forms, func(form sequencedForm) bool {
+		return form.word == word
+	})
+
+	if i == -1 {
+		sg.forms = append(sg.forms, sequencedForm{index: index, word: word})
+		return
+	}
+
+	sg.forms[i].index = min(sg.forms[i].index, index)
+}
+
+// anagramShard часть множеств анаграмм, в которую попадают слова с одинаковым остатком хеша сигнатуры от деления на
+// количество шардов, поэтому все анаграммы слова находятся в одном шарде
+type anagramShard struct {
+	groups map[uint64][]*shardGroup
+	seen   map[string]*shardGroup
+}
+
+// newAnagramShard конструктор для создания объекта структуры anagramShard
+func newAnagramShard() *anagramShard {
+	return &anagramShard{
+		groups: make(map[uint64][]*shardGroup),
+		seen:   make(map[string]*shardGroup),
+	}
+}
+
+// add метод, добавляющий слово в множество его анаграмм
+func (as *anagramShard) add(word sequencedWord) {
+	if group, exists := as.seen[word.lowerWord]; exists {
+		group.addForm(word.index, word.word)
+		return
+	}
+
+	var group *shardGroup
+
+	for _, candidate := range as.groups[word.hash] {
+		if isAnagram(candidate.words[0], word.lowerWord) {
+			group = candidate
+			break
+		}
+	}
+
+	if group == nil {
+		group = &shardGroup{}
+		as.groups[word.hash] = append(as.groups[word.hash], group)
+	}
+
+	as.seen[word.lowerWord] = group
+	group.words = append(group.words, word.lowerWord)
+	group.addForm(word.index, word.word)
+}
+
+// sets метод, возвращающий множества анаграмм шарда, в которых больше 1 слова: ключ - форма с наименьшим номером,
+// формы упорядочены по номеру первого появления, слова отсортированы по возрастанию
+func (as *anagramShard) sets() []AnagramSet {
+	var result []AnagramSet
+
+	for _, groups := range as.groups {
+		for _, group := range groups {
+			if len(group.words) < 2 {
+				continue
+			}
+
+			slices.SortFunc(group.forms, func(form1, form2 sequencedForm) int {
+				return cmp.Compare(form1.index, form2.index)
+			})
+
+			forms := make([]string, len(group.forms))
+			for i, form := range group.forms {
+				forms[i] = form.word
+			}
+
+			slices.Sort(group.words)
+
+			result = append(result, AnagramSet{
+				Key:        forms[0],
+				Words:      group.words,
+				FirstIndex: group.forms[0].index,
+				Forms:      forms,
+			})
+		}
+	}
+
+	return result
+}
+
+// ParallelOptions опции параллельного поиска множеств анаграмм
+type ParallelOptions struct {
+	// Workers количество горутин нормализации и шардов, 0 и меньше - по количеству процессоров
+	Workers int
+	// Normalizer приведение слов к виду для сравнения, nil - только нижний регистр
+	Normalizer *Normalizer
+}
+
+// FindAnagramSetsStream параллельный аналог FindOrderedAnagramSets, который читает слова из канала words до его
+// закрытия. Слова пачками нормализуются в нескольких горутинах и по хешу сигнатуры распределяются между таким же
+// количеством шардов, каждый из которых группирует свои слова в отдельной горутине. Слова нумеруются в порядке
+// чтения из канала, поэтому результат совпадает с результатом FindOrderedAnagramSets для тех же слов. При отмене
+// ctx чтение прекращается и возвращается ошибка контекста
+func FindAnagramSetsStream(ctx context.Context, words <-chan string, options ParallelOptions) ([]AnagramSet, error) {
+	workers := options.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	batches := make(chan []sequencedWord, workers)
+
+	shardInputs := make([]chan []sequencedWord, workers)
+	shards := make([]*anagramShard, workers)
+
+	for i := range shardInputs {
+		shardInputs[i] = make(chan []sequencedWord, workers)
+		shards[i] = newAnagramShard()
+	}
+
+	// горутины нормализации: слова пачки приводятся к нормализованному виду и раскладываются по шардам
+	var normalizers sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		normalizers.Add(1)
+
+		go func() {
+			defer normalizers.Done()
+
+			for batch := range batches {
+				shardBatches := make([][]sequencedWord, workers)
+
+				for _, word := range batch {
+					word.lowerWord = options.Normalizer.Normalize(word.word)
+					if word.lowerWord == "" {
+						continue
+					}
+
+					word.hash = signatureHash(word.lowerWord)
+
+					shard := word.hash % uint64(workers)
+					shardBatches[shard] = append(shardBatches[shard], word)
+				}
+
+				for shard, shardBatch := range shardBatches {
+					if len(shardBatch) > 0 {
+						shardInputs[shard] <- shardBatch
+					}
+				}
+			}
+		}()
+	}
+
+	// горутины шардов, каждая владеет своими мапами, поэтому блокировки не нужны
+	var grouping sync.WaitGroup
+
+	for i := range shards {
+		grouping.Add(1)
+
+		go func(shard *anagramShard, input <-chan []sequencedWord) {
+			defer grouping.Done()
+
+			for batch := range input {
+				for _, word := range batch {
+					shard.add(word)
+				}
+			}
+		}(shards[i], shardInputs[i])
+	}
+
+	err := dispatchWords(ctx, words, batches)
+
+	// после завершения горутин нормализации в шарды больше ничего не придет
+	close(batches)
+	normalizers.Wait()
+
+	for _, input := range shardInputs {
+		close(input)
+	}
+
+	grouping.Wait()
+
+	if err != nil {
+		return nil, err
+	}
+
+	// слияние результатов шардов, номера первых слов у множеств различны, поэтому порядок однозначен
+	result := make([]AnagramSet, 0)
+	for _, shard := range shards {
+		result = append(result, shard.sets()...)
+	}
+
+	slices.SortFunc(result, func(set1, set2 AnagramSet) int {
+		return cmp.Compare(set1.FirstIndex, set2.FirstIndex)
+	})
+
+	return result, nil
+}
+
+// dispatchWords читает слова из канала words, нумерует их и отправляет пачками в канал batches до закрытия words
+// или отмены ctx
+func dispatchWords(ctx context.Context, words <-chan string, batches chan<- []sequencedWord) error {
+	index := 0
+	batch := make([]sequencedWord, 0, parallelBatchSize)
+
+	send := func() error {
+		select {
+		case batches <- batch:
+			batch = make([]sequencedWord, 0, parallelBatchSize)
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	for {
+		select {
+		case word, ok := <-words:
+			if !ok {
+				if len(batch) == 0 {
+					return nil
+				}
+
+				return send()
+			}
+
+			batch = append(batch, sequencedWord{index: index, word: word})
+			index++
+
+			if len(batch) == parallelBatchSize {
+				if err := send(); err != nil {
+					return err
+				}
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// FindAnagramSetsReader аналог FindAnagramSetsStream, который читает слова из reader по одному на строке. Пустые
+// строки и пробельные символы по краям слов не учитываются, номера слов считаются только по непустым строкам
+func FindAnagramSetsReader(ctx context.Context, reader io.Reader, options ParallelOptions) ([]AnagramSet, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	words := make(chan string, parallelBatchSize)
+	readErr := make(chan error, 1)
+
+	go func() {
+		defer close(words)
+
+		scanner := bufio.NewScanner(reader)
+
+		for scanner.Scan() {
+			word := strings.TrimSpace(scanner.Text())
+			if word == "" {
+				continue
+			}
+
+			select {
+			case words <- word:
+			case <-ctx.Done():
+				readErr <- ctx.Err()
+				return
+			}
+		}
+
+		readErr <- scanner.Err()
+	}()
+
+	sets, err := FindAnagramSetsStream(ctx, words, options)
+	if err != nil {
+		return nil, err
+	}
+
+	// канал слов закрыт, значит горутина чтения уже записала свою ошибку
+	if err := <-readErr; err != nil {
+		return nil, err
+	}
+
+	return sets, nil
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"unicode"
+	"unicode/utf8"
+)
+
+// searchCheckInterval количество шагов поиска между проверками отмены контекста
+const searchCheckInterval = 1024
+
+// runeCounts вектор количества вхождений символов алфавита в слово, индекс - номер символа в алфавите
+type runeCounts []int
+
+// letterAlphabet алфавит набора букв: номер каждой буквы и вектор количества её вхождений в набор
+type letterAlphabet struct {
+	indexes map[rune]int
+	counts  runeCounts
+	total   int
+}
+
+// newLetterAlphabet возвращает алфавит букв уже нормализованной строки letters, символы, не являющиеся буквами,
+// диакритическими знаками или цифрами (пробелы, знаки препинания), не учитываются
+func newLetterAlphabet(letters string) *letterAlphabet {
+	la := &letterAlphabet{indexes: make(map[rune]int)}
+
+	for _, r := range letters {
+		if !unicode.In(r, unicode.L, unicode.M, unicode.Nd) {
+			continue
+		}
+
+		index, ok := la.indexes[r]
+		if !ok {
+			index = len(la.counts)
+			la.indexes[r] = index
+			la.counts = append(la.counts, 0)
+		}
+
+		la.counts[index]++
+		la.total++
+	}
+
+	return la
+}
+
+// count метод, возвращающий вектор количества вхождений символов сигнатуры в алфавите и false, если сигнатура
+// содержит символы не из алфавита или символов больше, чем в наборе букв
+func (la *letterAlphabet) count(signature string) (runeCounts, bool) {
+	counts := make(runeCounts, len(la.counts))
+
+	for _, r := range signature {
+		index, ok := la.indexes[r]
+		if !ok {
+			return nil, false
+		}
+
+		counts[index]++
+		if counts[index] > la.counts[index] {
+			return nil, false
+		}
+	}
+
+	return counts, true
+}
+
+// searchCandidate множество анаграмм, которое можно составить из набора букв
+type searchCandidate struct {
+	signature string
+	counts    runeCounts
+	length    int
+	words     []string
+}
+
+// candidates метод, возвращающий множества индекса, которые можно составить из букв алфавита, упорядоченные по
+// убыванию длины слов, затем по сигнатуре
+func (ai *AnagramIndex) candidates(ctx context.Context, alphabet *letterAlphabet) ([]searchCandidate, error) {
+	var candidates []searchCandidate
+
+	steps := 0
+
+	err := ai.Range(func(signature string, words []string) bool {
+		steps++
+		if steps%searchCheckInterval == 0 && ctx.Err() != nil {
+			return false
+		}
+
+		counts, ok := alphabet.count(signature)
+		if ok {
+			candidates = append(candidates, searchCandidate{
+				signature: signature,
+				counts:    counts,
+				length:    utf8.RuneCountInString(signature),
+				words:     words,
+			})
+		}
+
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(candidates, func(candidate1, candidate2 searchCandidate) int {
+		if candidate1.length != candidate2.length {
+			return cmp.Compare(candidate2.length, candidate1.length)
+		}
+
+		return cmp.Compare(candidate1.signature, candidate2.signature)
+	})
+
+	return candidates, nil
+}
+
+// SubAnagrams метод, возвращающий слова индекса, которые можно составить из части букв letters (каждая буква
+// используется не больше раз, чем встречается в letters), упорядоченные по убыванию длины, затем по алфавиту. limit
+// ограничивает количество результатов, 0 - без ограничения. При отмене контекста возвращается ошибка контекста
+func (ai *AnagramIndex) SubAnagrams(ctx context.Context, letters string, limit int) ([]string, error) {
+	candidates, err := ai.candidates(ctx, newLetterAlphabet(ai.normalizer.Normalize(letters)))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0)
+
+	for _, candidate := range candidates {
+		for _, word := range candidate.words {
+			if limit > 0 && len(result) == limit {
+				return result, nil
+			}
+
+			result = append(result, word)
+		}
+	}
+
+	return result, nil
+}
+
+// phraseSearch состояние поиска многословных анаграмм
+type phraseSearch struct {
+	ctx        context.Context
+	candidates []searchCandidate
+	remaining  runeCounts
+	left       int
+	maxWords   int
+	limit      int
+	chosen     []int
+	result     [][]string
+	steps      int
+}
+
+// PhraseAnagrams метод, возвращающий фразы из не более чем maxWords слов индекса, которые составлены ровно из всех
+// букв phrase (пробелы и знаки препинания не учитываются), например "dormitory" - "dirty room". Слова во фразе идут
+// по убыванию длины, перестановки одной фразы не повторяются. limit ограничивает количество результатов, 0 - без
+// ограничения. При отмене контекста возвращаются найденные фразы и ошибка контекста
+func (ai *AnagramIndex) PhraseAnagrams(ctx context.Context, phrase string, maxWords, limit int) ([][]string, error) {
+	alphabet := newLetterAlphabet(ai.normalizer.Normalize(phrase))
+
+	candidates, err := ai.candidates(ctx, alphabet)
+	if err != nil {
+		return nil, err
+	}
+
+	ps := &phraseSearch{
+		ctx:        ctx,
+		candidates: candidates,
+		remaining:  slices.Clone(alphabet.counts),
+		left:       alphabet.total,
+		maxWords:   maxWords,
+		limit:      limit,
+		result:     make([][]string, 0),
+	}
+
+	if alphabet.total == 0 {
+		return ps.result, nil
+	}
+
+	err = ps.search(0)
+
+	return ps.result, err
+}
+
+// done метод, проверяющий, достигнут ли лимит результатов
+func (ps *phraseSearch) done() bool {
+	return ps.limit > 0 && len(ps.result) >= ps.limit
+}
+
+// search метод поиска с возвратом: к выбранным множествам добавляется множество с номером не меньше start, которое
+// помещается в оставшиеся буквы. Ветви отсекаются, если оставшиеся буквы нельзя покрыть оставшимся числом слов
+func (ps *phraseSearch) search(start int) error {
+	if ps.left == 0 {
+		ps.expand(0, 0, make([]string, 0, len(ps.chosen)))
+		return nil
+	}
+
+	wordsLeft := ps.maxWords - len(ps.chosen)
+	if wordsLeft == 0 {
+		return nil
+	}
+
+	for i := start; i < len(ps.candidates) && !ps.done(); i++ {
+		candidate := ps.candidates[i]
+
+		// кандидаты упорядочены по убыванию длины, поэтому если самые длинные из оставшихся слов не покрывают
+		// оставшиеся буквы, то следующие кандидаты тоже не покроют
+		if candidate.length*wordsLeft < ps.left {
+			return nil
+		}
+
+		if candidate.length > ps.left || !ps.fits(candidate.counts) {
+			continue
+		}
+
+		ps.steps++
+		if ps.steps%searchCheckInterval == 0 {
+			if err := ps.ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		ps.apply(candidate, -1)
+		ps.chosen = append(ps.chosen, i)
+
+		err := ps.search(i)
+
+		ps.chosen = ps.chosen[:len(ps.chosen)-1]
+		ps.apply(candidate, 1)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fits метод, проверяющий, что буквы кандидата есть среди оставшихся
+func (ps *phraseSearch) fits(counts runeCounts) bool {
+	for i, count := range counts {
+		if count > ps.remaining[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// apply метод, вычитающий (sign = -1) или возвращающий (sign = 1) буквы кандидата из оставшихся
+func (ps *phraseSearch) apply(candidate searchCandidate, sign int) {
+	for i, count := range candidate.counts {
+		ps.remaining[i] += sign * count
+	}
+
+	ps.left += sign * candidate.length
+}
+
+// expand метод, раскрывающий выбранные множества в фразы: для каждого множества выбирается одно из его слов. Для
+// повторяющихся множеств слова выбираются в неубывающем порядке, чтобы не повторять перестановки
+func (ps *phraseSearch) expand(position, from int, phrase []string) {
+	if ps.done() {
+		return
+	}
+
+	if position == len(ps.chosen) {
+		ps.result = append(ps.result, slices.Clone(phrase))
+		return
+	}
+
+	// для нового множества слова выбираются с начала
+	if position > 0 && ps.chosen[position] != ps.chosen[position-1] {
+		from = 0
+	}
+
+	words := ps.candidates[ps.chosen[position]].words
+
+	for i := from; i < len(words); i++ {
+		ps.expand(position+1, i, append(phrase, words[i]))
+	}
+}
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// serverCacheSize количество запоминаемых ответов сервера, запущенного опцией --serve
+	serverCacheSize = 4096
+	// serverMaxBodySize наибольший размер словаря, загружаемого в сервер, запущенный опцией --serve
+	serverMaxBodySize = 256 << 20
+	// defaultPageLimit количество множеств на странице списка по умолчанию
+	defaultPageLimit = 20
+	// maxPageLimit наибольшее количество множеств на странице списка
+	maxPageLimit = 1000
+	// shutdownTimeout время ожидания завершения обрабатываемых запросов при остановке сервера
+	shutdownTimeout = 10 * time.Second
+)
+
+var (
+	errNoDictionary = errors.New("dictionary is not loaded")
+	errMethod       = errors.New("method not allowed")
+	errNoWord       = errors.New("missing word parameter")
+	errOffset       = errors.New("invalid offset, expected non-negative integer")
+	errLimit        = errors.New("invalid limit, expected integer from 1 to 1000")
+	errMinSize      = errors.New("invalid min_size, expected integer not less than 2")
+	errBodyTooLarge = errors.New("dictionary is too large")
+)
+
+// lruEntry элемент кеша
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// lruCache потокобезопасный кеш ограниченного размера, при переполнении вытесняющий давно не использованные значения
+type lruCache[K comparable, V any] struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[K]*list.Element
+	order    *list.List
+}
+
+// newLRUCache конструктор для создания объекта структуры lruCache, при capacity 0 и меньше кеш ничего не хранит
+func newLRUCache[K comparable, V any](capacity int) *lruCache[K, V] {
+	return &lruCache[K, V]{
+		capacity: capacity,
+		items:    make(map[K]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// get метод, возвращающий значение по ключу и признак его наличия в кеше
+func (lc *lruCache[K, V]) get(key K) (V, bool) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	element, exists := lc.items[key]
+	if !exists {
+		var zero V
+		return zero, false
+	}
+
+	lc.order.MoveToFront(element)
+
+	return element.Value.(*lruEntry[K, V]).value, true
+}
+
+// put метод, сохраняющий значение по ключу и вытесняющий самое давно использованное значение при переполнении
+func (lc *lruCache[K, V]) put(key K, value V) {
+	if lc.capacity <= 0 {
+		return
+	}
+
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	if element, exists := lc.items[key]; exists {
+		element.Value.(*lruEntry[K, V]).value = value
+		lc.order.MoveToFront(element)
+
+		return
+	}
+
+	lc.items[key] = lc.order.PushFront(&lruEntry[K, V]{key: key, value: value})
+
+	if lc.order.Len() > lc.capacity {
+		oldest := lc.order.Back()
+		lc.order.Remove(oldest)
+		delete(lc.items, oldest.Value.(*lruEntry[K, V]).key)
+	}
+}
+
+// listKey параметры списка множеств анаграмм, по которым кешируется упорядоченный список
+type listKey struct {
+	sortBy  string
+	minSize int
+}
+
+// serverDictionary загруженный словарь с найденными множествами анаграмм, индексом и кешами ответов. При загрузке
+// нового словаря заменяется целиком, поэтому кеши старого словаря сбрасываются вместе с ним
+type serverDictionary struct {
+	sets    []AnagramSet
+	index   *AnagramIndex
+	stats   AnagramStats
+	lookups *lruCache[string, []string]
+	lists   *lruCache[listKey, []AnagramSet]
+}
+
+// ServerOptions опции HTTP-сервера поиска анаграмм
+type ServerOptions struct {
+	// Mode режим чтения загружаемого словаря по умолчанию: lines или text
+	Mode string
+	// Normalizer приведение слов к виду для сравнения, nil - только нижний регистр
+	Normalizer *Normalizer
+	// CacheSize количество запоминаемых ответов на запросы анаграмм слова и списков множеств, 0 - без кеша
+	CacheSize int
+	// MaxBodySize наибольший размер загружаемого словаря в байтах, 0 - без ограничения
+	MaxBodySize int64
+}
+
+// AnagramServer HTTP-сервер поиска анаграмм. Методы API:
+//
+//	POST /load?mode=lines|text - загрузка словаря из тела запроса вместо текущего, ответ - статистика словаря
+//	GET /anagrams?word=... - анаграммы слова из словаря, не включая само слово
+//	GET /sets?offset=0&limit=20&sort_by=key|size|first&min_size=2 - страница списка множеств анаграмм
+//
+// Ответ - JSON-документ {"result": ...} в случае успеха или {"error": "..."} в случае ошибки
+type AnagramServer struct {
+	options    ServerOptions
+	dictionary atomic.Pointer[serverDictionary]
+}
+
+// NewAnagramServer конструктор для создания объекта структуры AnagramServer без загруженного словаря
+func NewAnagramServer(options ServerOptions) *AnagramServer {
+	if options.Mode == "" {
+		options.Mode = "lines"
+	}
+
+	return &AnagramServer{options: options}
+}
+
+// Load метод, заменяющий словарь сервера на words и возвращающий его статистику. Запросы, которые уже
+// обрабатываются, завершаются со старым словарем
+func (as *AnagramServer) Load(words []string) AnagramStats {
+	sets := FindOrderedAnagramSets(words, as.options.Normalizer)
+
+	dictionary := &serverDictionary{
+		sets:    sets,
+		index:   NewNormalizedAnagramIndex(words, as.options.Normalizer),
+		stats:   dictionaryStats(words, as.options.Normalizer, sets),
+		lookups: newLRUCache[string, []string](as.options.CacheSize),
+		lists:   newLRUCache[listKey, []AnagramSet](as.options.CacheSize),
+	}
+
+	as.dictionary.Store(dictionary)
+
+	return dictionary.stats
+}
+
+// Handler метод, возвращающий обработчик HTTP-запросов к API сервера
+func (as *AnagramServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/load", as.handleLoad)
+	mux.HandleFunc("/anagrams", as.handleAnagrams)
+	mux.HandleFunc("/sets", as.handleSets)
+
+	return mux
+}
+
+// Serve метод, принимающий соединения listener до отмены ctx, после отмены сервер перестает принимать соединения и
+// ждет завершения обрабатываемых запросов не дольше shutdownTimeout
+func (as *AnagramServer) Serve(ctx context.Context, listener net.Listener) error {
+	server := &http.Server{
+		Handler:           as.Handler(),
+		ReadHeaderTimeout: shutdownTimeout,
+	}
+
+	serveErr := make(chan error, 1)
+
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	err := server.Shutdown(shutdownCtx)
+	if err != nil {
+		return err
+	}
+
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// ListenAndServe метод, запускающий сервер на адресе addr до отмены ctx
+func (as *AnagramServer) ListenAndServe(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return as.Serve(ctx, listener)
+}
+
+// serverResponse ответ сервера
+type serverResponse struct {
+	Result any    `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// writeResponse записывает ответ сервера в формате JSON с кодом status
+func writeResponse(writer http.ResponseWriter, status int, response serverResponse) {
+	writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+	writer.WriteHeader(status)
+
+	_ = json.NewEncoder(writer).Encode(response)
+}
+
+// writeError записывает ответ сервера с ошибкой err и кодом status
+func writeError(writer http.ResponseWriter, status int, err error) {
+	writeResponse(writer, status, serverResponse{Error: err.Error()})
+}
+
+// checkMethod проверяет метод запроса, в случае несовпадения записывает ответ с ошибкой и возвращает false
+func checkMethod(writer http.ResponseWriter, request *http.Request, method string) bool {
+	if request.Method != method {
+		writer.Header().Set("Allow", method)
+		writeError(writer, http.StatusMethodNotAllowed, errMethod)
+
+		return false
+	}
+
+	return true
+}
+
+// currentDictionary метод, возвращающий загруженный словарь, в случае его отсутствия записывает ответ с ошибкой и
+// возвращает nil
+func (as *AnagramServer) currentDictionary(writer http.ResponseWriter) *serverDictionary {
+	dictionary := as.dictionary.Load()
+	if dictionary == nil {
+		writeError(writer, http.StatusServiceUnavailable, errNoDictionary)
+	}
+
+	return dictionary
+}
+
+// handleLoad метод, обрабатывающий загрузку словаря
+func (as *AnagramServer) handleLoad(writer http.ResponseWriter, request *http.Request) {
+	if !checkMethod(writer, request, http.MethodPost) {
+		return
+	}
+
+	mode := request.URL.Query().Get("mode")
+	if mode == "" {
+		mode = as.options.Mode
+	}
+
+	if !slices.Contains([]string{"lines", "text"}, mode) {
+		writeError(writer, http.StatusBadRequest, errMode)
+		return
+	}
+
+	body := request.Body
+	if as.options.MaxBodySize > 0 {
+		body = http.MaxBytesReader(writer, body, as.options.MaxBodySize)
+	}
+
+	words, err := readDictionary(body, mode)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeError(writer, http.StatusRequestEntityTooLarge, errBodyTooLarge)
+			return
+		}
+
+		writeError(writer, http.StatusBadRequest, err)
+
+		return
+	}
+
+	writeResponse(writer, http.StatusOK, serverResponse{Result: as.Load(words)})
+}
+
+// handleAnagrams метод, обрабатывающий запрос анаграмм слова
+func (as *AnagramServer) handleAnagrams(writer http.ResponseWriter, request *http.Request) {
+	if !checkMethod(writer, request, http.MethodGet) {
+		return
+	}
+
+	word := strings.TrimSpace(request.URL.Query().Get("word"))
+	if word == "" {
+		writeError(writer, http.StatusBadRequest, errNoWord)
+		return
+	}
+
+	dictionary := as.currentDictionary(writer)
+	if dictionary == nil {
+		return
+	}
+
+	// ответ зависит только от нормализованного слова, поэтому разные написания слова используют одну запись кеша
+	lowerWord := as.options.Normalizer.Normalize(word)
+
+	anagrams, cached := dictionary.lookups.get(lowerWord)
+	if !cached {
+		var err error
+
+		anagrams, err = dictionary.index.Lookup(word)
+		if err != nil {
+			writeError(writer, http.StatusInternalServerError, err)
+			return
+		}
+
+		dictionary.lookups.put(lowerWord, anagrams)
+	}
+
+	writeResponse(writer, http.StatusOK, serverResponse{Result: struct {
+		Key   string   `json:"key"`
+		Words []string `json:"words"`
+	}{Key: word, Words: anagrams}})
+}
+
+// queryInt возвращает целочисленный параметр запроса name, fallback - если параметра нет, и признак корректности
+// значения
+func queryInt(request *http.Request, name string, fallback int) (int, bool) {
+	value := request.URL.Query().Get(name)
+	if value == "" {
+		return fallback, true
+	}
+
+	number, err := strconv.Atoi(value)
+
+	return number, err == nil
+}
+
+// handleSets метод, обрабатывающий запрос страницы списка множеств анаграмм
+func (as *AnagramServer) handleSets(writer http.ResponseWriter, request *http.Request) {
+	if !checkMethod(writer, request, http.MethodGet) {
+		return
+	}
+
+	offset, ok := queryInt(request, "offset", 0)
+	if !ok || offset < 0 {
+		writeError(writer, http.StatusBadRequest, errOffset)
+		return
+	}
+
+	limit, ok := queryInt(request, "limit", defaultPageLimit)
+	if !ok || limit < 1 || limit > maxPageLimit {
+		writeError(writer, http.StatusBadRequest, errLimit)
+		return
+	}
+
+	minSize, ok := queryInt(request, "min_size", 2)
+	if !ok || minSize < 2 {
+		writeError(writer, http.StatusBadRequest, errMinSize)
+		return
+	}
+
+	sortBy := request.URL.Query().Get("sort_by")
+	if sortBy == "" {
+		sortBy = "key"
+	}
+
+	if !slices.Contains([]string{"key", "size", "first"}, sortBy) {
+		writeError(writer, http.StatusBadRequest, errSortBy)
+		return
+	}
+
+	dictionary := as.currentDictionary(writer)
+	if dictionary == nil {
+		return
+	}
+
+	key := listKey{sortBy: sortBy, minSize: minSize}
+
+	sets, cached := dictionary.lists.get(key)
+	if !cached {
+		sets = filterSets(dictionary.sets, minSize, sortBy)
+		dictionary.lists.put(key, sets)
+	}
+
+	start := min(offset, len(sets))
+	page := sets[start:min(start+limit, len(sets))]
+
+	writeResponse(writer, http.StatusOK, serverResponse{Result: struct {
+		Total  int          `json:"total"`
+		Offset int          `json:"offset"`
+		Limit  int          `json:"limit"`
+		Sets   []AnagramSet `json:"sets"`
+	}{Total: len(sets), Offset: offset, Limit: limit, Sets: page}})
+}
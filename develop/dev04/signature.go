@@ -0,0 +1,97 @@
+package main
+
+import "wb-level-2/develop/dev04/utils"
+
+// mix возвращает перемешанное значение (финализатор splitmix64), чтобы сумма значений символов слова равномерно
+// распределялась
+func mix(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+
+	return x ^ (x >> 31)
+}
+
+// clusterHash возвращает хеш графемного кластера, для кластера из одного символа - перемешанный код символа
+func clusterHash(cluster string) uint64 {
+	var hash uint64
+
+	for i, r := range cluster {
+		if i == 0 {
+			hash = mix(uint64(r))
+		} else {
+			hash = mix(hash ^ uint64(r))
+		}
+	}
+
+	return hash
+}
+
+// signatureHash возвращает хеш мультимножества графемных кластеров слова без выделения памяти: сумма хешей кластеров
+// не зависит от их порядка, поэтому у анаграмм хеши совпадают. Разные мультимножества могут иметь одинаковый хеш,
+// поэтому совпадение хешей проверяется функцией isAnagram
+func signatureHash(word string) uint64 {
+	var hash uint64
+
+	for rest := word; rest != ""; {
+		var cluster string
+
+		cluster, rest = utils.NextCluster(rest)
+		hash += clusterHash(cluster)
+	}
+
+	return hash
+}
+
+// isAnagram проверяет, состоят ли слова из одинаковых графемных кластеров с одинаковым количеством вхождений, без
+// выделения памяти: для каждого кластера word1, встреченного впервые, сравнивается количество его вхождений в оба
+// слова
+func isAnagram(word1, word2 string) bool {
+	if len(word1) != len(word2) {
+		return false
+	}
+
+	for rest := word1; rest != ""; {
+		var cluster string
+
+		start := len(word1) - len(rest)
+		cluster, rest = utils.NextCluster(rest)
+
+		if countCluster(word1[:start], cluster) > 0 {
+			continue
+		}
+
+		if countCluster(word1[start:], cluster) != countCluster(word2, cluster) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// countCluster возвращает количество вхождений графемного кластера в слово
+func countCluster(word, target string) int {
+	count := 0
+
+	for rest := word; rest != ""; {
+		var cluster string
+
+		cluster, rest = utils.NextCluster(rest)
+		if cluster == target {
+			count++
+		}
+	}
+
+	return count
+}
+
+// findGroup возвращает множество анаграмм слова из множеств с тем же хешем сигнатуры, nil - если такого множества нет
+func findGroup(groups []*AnagramSet, lowerWord string) *AnagramSet {
+	for _, group := range groups {
+		if isAnagram(group.Words[0], lowerWord) {
+			return group
+		}
+	}
+
+	return nil
+}
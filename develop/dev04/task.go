@@ -1,8 +1,23 @@
 package main
 
 import (
-	"sort"
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
 	"strings"
+	"syscall"
+	"wb-level-2/develop/dev04/lexicon"
+	"wb-level-2/develop/dev04/utils"
 )
 
 /*
@@ -24,6 +39,14 @@ import (
 Программа должна проходить все тесты. Код должен проходить проверки go vet и golint.
 */
 
+var (
+	errMode     = errors.New("unknown input mode, expected lines or text")
+	errFormat   = errors.New("unknown output format, expected text, json or csv")
+	errSortBy   = errors.New("unknown sort order, expected key, size or first")
+	errDistance = errors.New("invalid edit distance, expected 0 to 3")
+	errLadder   = errors.New("invalid ladder, expected two comma-separated words")
+)
+
 // ToLowerUnique принимает на вход слайс строк, возвращает слайс уникальных строк в нижнем регистре
 func ToLowerUnique(words []string) []string {
 	// создаем мапу для проверки уникальности, в качестве ключа строку в нижнем регистре, в значение true - если такое
@@ -49,63 +72,729 @@ func ToLowerUnique(words []string) []string {
 	return result
 }
 
-// SortWord принимает на вход строку, возвращает строку, отсортированную по символам
+// SortWord принимает на вход строку, возвращает строку, отсортированную по графемным кластерам: символы вместе со
+// следующими за ними диакритическими знаками переставляются как одно целое
 func SortWord(word string) string {
-	// разделяем посимвольно строку, получаем слайс символов строки
-	wordArr := strings.Split(word, "")
-	// сортируем слайс символов
-	sort.Strings(wordArr)
-	// соединям в новом порядке символы в строку
-	return strings.Join(wordArr, "")
+	// соединям в новом порядке кластеры в строку
+	return strings.Join(sortedClusters(word), "")
+}
+
+// sortedClusters принимает на вход строку, возвращает отсортированный слайс её графемных кластеров
+func sortedClusters(word string) []string {
+	// разделяем строку на графемные кластеры, получаем слайс кластеров строки
+	clusters := utils.Clusters(word)
+
+	// сортируем слайс кластеров
+	slices.Sort(clusters)
+
+	return clusters
 }
 
 // FindAnagramSets принимает на вход слайс строк, возвращает мапу, где в качестве ключей первое встретившееся в слайсе
 // слово, в значении слайс уникальных строк в нижнем регистре, которые являются анаграммой ключа, количество элементов
 // которого больше 1
 func FindAnagramSets(words []string) map[string][]string {
-	// мапа для нахождения анаграмм
-	anagramSets := make(map[string][]string)
-	// мапа для переименовывания ключей из отсортированных символов в первое встретившееся в слайсе строк
-	keys := make(map[string]string)
-
-	// проходимся циклом по слайсу строк, каждую строку переводим в нижний регистр и сортируем посимвольно, в ключ keys
-	// записываем слово, отсортированное посимвольно в нижнем регистре, а в значение - первое встретившееся слово в
-	// words
-	for _, word := range words {
-		lowerWord := strings.ToLower(word)
-		sortedLowerWord := SortWord(lowerWord)
+	return FindNormalizedAnagramSets(words, nil)
+}
+
+// FindNormalizedAnagramSets аналог FindAnagramSets, который сравнивает слова и возвращает их в значениях мапы в виде,
+// приведенном normalizer (nil - только нижний регистр). Слова, которые после нормализации оказались пустыми,
+// пропускаются
+func FindNormalizedAnagramSets(words []string, normalizer *Normalizer) map[string][]string {
+	result := make(map[string][]string)
+
+	for _, set := range FindOrderedAnagramSets(words, normalizer) {
+		result[set.Key] = set.Words
+	}
+
+	return result
+}
 
-		_, exists := keys[sortedLowerWord]
-		if !exists {
-			keys[sortedLowerWord] = word
+// AnagramSet множество анаграмм
+type AnagramSet struct {
+	// Key первое встретившееся слово множества в исходном виде
+	Key string `json:"key"`
+	// Words уникальные слова множества в нормализованном виде (по умолчанию в нижнем регистре), отсортированные по
+	// возрастанию
+	Words []string `json:"words"`
+	// FirstIndex индекс первого встретившегося слова множества во входных данных
+	FirstIndex int `json:"first_index"`
+	// Forms уникальные слова множества в исходном виде в порядке их появления во входных данных
+	Forms []string `json:"forms"`
+	// Distances расстояния от ключа до слов Words (в том же порядке) в количестве вставок, удалений и замен символов
+	// без учета их порядка, заполняются только функцией FindNearAnagramSets
+	Distances []int `json:"distances,omitempty"`
+}
+
+// FindOrderedAnagramSets принимает на вход слайс строк и normalizer (nil - только нижний регистр), возвращает слайс
+// множеств анаграмм, в которых больше 1 слова, упорядоченный по первому появлению слов множества во входных данных.
+// Результат не зависит от порядка обхода мап, поэтому воспроизводим от запуска к запуску. Слова, которые после
+// нормализации оказались пустыми, пропускаются
+func FindOrderedAnagramSets(words []string, normalizer *Normalizer) []AnagramSet {
+	ordered, _ := groupAnagrams(words, normalizer)
+
+	// в результат попадают множества, в которых больше 1 слова, слова множества сортируются
+	result := make([]AnagramSet, 0)
+
+	for _, group := range ordered {
+		if len(group.Words) > 1 {
+			slices.Sort(group.Words)
+			result = append(result, *group)
 		}
 	}
 
-	// меняем значение words на слайс из уникальных слов из words в нижнем регистре
-	words = ToLowerUnique(words)
+	return result
+}
+
+// groupAnagrams принимает на вход слайс строк и normalizer, возвращает все множества анаграмм, включая множества из
+// одного слова, в порядке первого появления (слова множеств не отсортированы) и мапу, в которой каждому
+// нормализованному слову соответствует его множество
+func groupAnagrams(words []string, normalizer *Normalizer) ([]*AnagramSet, map[string]*AnagramSet) {
+	// множества анаграмм по хешу сигнатуры, при совпадении хешей у разных множеств они хранятся в одном слайсе
+	groups := make(map[uint64][]*AnagramSet)
+	// множества анаграмм в порядке появления
+	var ordered []*AnagramSet
+	// мапа для проверки уникальности слов в нижнем регистре, в значении - множество, в котором находится слово
+	seen := make(map[string]*AnagramSet)
+
+	// проходимся циклом по слайсу строк один раз: каждое новое слово в нижнем регистре добавляем в множество его
+	// анаграмм, сравнивая его с первым словом множества по количеству символов, новое множество получает ключом само
+	// слово. Повторное слово уже находится в своем множестве, в него добавляется только новая исходная форма слова
+	for i, word := range words {
+		lowerWord := normalizer.Normalize(word)
+		if lowerWord == "" {
+			continue
+		}
+
+		if group, exists := seen[lowerWord]; exists {
+			if !slices.Contains(group.Forms, word) {
+				group.Forms = append(group.Forms, word)
+			}
+
+			continue
+		}
+
+		hash := signatureHash(lowerWord)
+
+		group := findGroup(groups[hash], lowerWord)
+		if group == nil {
+			group = &AnagramSet{Key: word, FirstIndex: i}
+			groups[hash] = append(groups[hash], group)
+			ordered = append(ordered, group)
+		}
+
+		seen[lowerWord] = group
+		group.Words = append(group.Words, lowerWord)
+
+		if !slices.Contains(group.Forms, word) {
+			group.Forms = append(group.Forms, word)
+		}
+	}
+
+	return ordered, seen
+}
+
+// AnagramFlags структура, определяющая опции утилиты поиска анаграмм
+type AnagramFlags struct {
+	mode        string
+	format      string
+	minSize     int
+	sortBy      string
+	stats       bool
+	buildIndex  string
+	index       string
+	normalize   NormalizeOptions
+	workers     int
+	distance    int
+	serve       string
+	palindromes bool
+	ladder      string
+}
+
+// Parse метод для распарсивания и сохранения значений флагов опций в поля структуры AnagramFlags
+func (af *AnagramFlags) Parse() {
+	flag.StringVar(&af.mode, "mode", "lines", "Input mode: lines (one word per line) or text (words of free text)")
+	flag.StringVar(&af.format, "format", "text", "Output format: text, json or csv")
+	flag.IntVar(&af.minSize, "min-size", 2, "Output only sets with at least N words")
+	flag.StringVar(&af.sortBy, "sort-by", "key", "Order of sets: key, size (largest first) or first (input order)")
+	flag.BoolVar(&af.stats, "stats", false, "Print dictionary and anagram statistics to standard error")
+	flag.StringVar(&af.buildIndex, "build-index", "", "Build an anagram index of the dictionary and save it to file")
+	flag.StringVar(&af.index, "index", "", "Look up anagrams of input words in a prebuilt index file")
+	flag.StringVar(&af.normalize.Form, "normalize", "", "Unicode normalization form of words: nfc or nfd")
+	flag.BoolVar(&af.normalize.FoldDiacritics, "fold-diacritics", false, "Remove diacritics, e.g. ё as е")
+	flag.StringVar(&af.normalize.Locale, "locale", "", "Language of case folding, e.g. tr for dotted and dotless i")
+	flag.BoolVar(&af.normalize.IgnorePunctuation, "ignore-punct", false, "Ignore punctuation and spaces in words")
+	flag.IntVar(&af.workers, "workers", 1, "Number of goroutines grouping words, 0 - number of CPUs")
+	flag.IntVar(&af.distance, "distance", 0, "Group near anagrams differing by at most N letter edits (0-3)")
+	flag.StringVar(&af.serve, "serve", "", "Start an HTTP anagram service on address, e.g. :8080")
+	flag.BoolVar(&af.palindromes, "palindromes", false, "Print palindromes of the dictionary instead of anagram sets")
+	flag.StringVar(&af.ladder, "ladder", "", "Print the shortest word ladder between two words, e.g. кот,мир")
+
+	flag.Parse()
+}
+
+// AnagramArgs структура, определяющая неименованные аргументы запуска утилиты поиска анаграмм
+type AnagramArgs struct {
+	inputFiles []*os.File
+}
+
+// Parse метод для распарсивания и сохранения значений неименованных аргументов запуска утилиты в поля структуры
+// AnagramArgs: пути до файлов словаря, если их нет - словарь читается из os.Stdin
+func (aa *AnagramArgs) Parse() error {
+	if flag.NArg() == 0 {
+		aa.inputFiles = append(aa.inputFiles, os.Stdin)
+		return nil
+	}
+
+	for _, arg := range flag.Args() {
+		inputFile, err := os.Open(arg)
+		if err != nil {
+			return err
+		}
+
+		aa.inputFiles = append(aa.inputFiles, inputFile)
+	}
+
+	return nil
+}
+
+// AnagramStats статистика словаря и найденных анаграмм
+type AnagramStats struct {
+	Words      int `json:"words"`
+	Unique     int `json:"unique"`
+	Sets       int `json:"sets"`
+	Anagrams   int `json:"anagrams"`
+	LargestSet int `json:"largest_set"`
+}
+
+// AnagramClient структура для управления утилитой поиска анаграмм
+type AnagramClient struct {
+	flags      AnagramFlags
+	args       AnagramArgs
+	data       []string
+	index      *AnagramIndex
+	normalizer *Normalizer
+}
+
+// NewAnagramClient конструктор для создания объекта структуры AnagramClient
+func NewAnagramClient() (*AnagramClient, error) {
+	// создание пустого объекта структуры AnagramClient
+	ac := &AnagramClient{}
+
+	// парс флагов и аргументов запуска утилиты
+	ac.flags.Parse()
+	err := ac.args.Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	// проверка опций --mode, --format, --sort-by, --distance, --ladder
+	if !slices.Contains([]string{"lines", "text"}, ac.flags.mode) {
+		return nil, errMode
+	}
+
+	if !slices.Contains([]string{"text", "json", "csv"}, ac.flags.format) {
+		return nil, errFormat
+	}
+
+	if !slices.Contains([]string{"key", "size", "first"}, ac.flags.sortBy) {
+		return nil, errSortBy
+	}
+
+	if ac.flags.distance < 0 || ac.flags.distance > maxNearDistance {
+		return nil, errDistance
+	}
+
+	from, to, found := strings.Cut(ac.flags.ladder, ",")
+	if ac.flags.ladder != "" && (!found || strings.TrimSpace(from) == "" || strings.TrimSpace(to) == "") {
+		return nil, errLadder
+	}
+
+	// учитывание опций --normalize, --fold-diacritics, --locale, --ignore-punct
+	ac.normalizer, err = NewNormalizer(ac.flags.normalize)
+	if err != nil {
+		return nil, err
+	}
+
+	// учитывание опции --index, входные данные - запросы, которые читаются построчно во время работы утилиты
+	if ac.flags.index != "" {
+		ac.index, err = LoadAnagramIndex(ac.flags.index)
+		if err != nil {
+			return nil, err
+		}
+
+		ac.index.SetNormalizer(ac.normalizer)
+
+		return ac, nil
+	}
+
+	// учитывание опции --serve, без входных файлов сервер запускается без словаря, который загружается через API
+	if ac.flags.serve != "" && flag.NArg() == 0 {
+		return ac, nil
+	}
+
+	// чтение и сохранение слов словаря в поле data структуры AnagramClient, закрытие reader'ов
+	for _, inputFile := range ac.args.inputFiles {
+		words, err := ac.readWords(inputFile)
+		_ = inputFile.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		ac.data = append(ac.data, words...)
+	}
+
+	return ac, nil
+}
+
+// readWords метод, читающий слова словаря из reader в соответствии с опцией --mode
+func (ac *AnagramClient) readWords(reader io.Reader) ([]string, error) {
+	return readDictionary(reader, ac.flags.mode)
+}
+
+// readDictionary читает слова словаря из reader в режиме mode: lines - по одному слову на строке, text - слова
+// свободного текста
+func readDictionary(reader io.Reader, mode string) ([]string, error) {
+	switch mode {
+	case "lines":
+		lines, err := utils.ReadData(reader)
+		if err != nil {
+			return nil, err
+		}
+
+		// пустые строки и пробельные символы по краям слов не учитываются
+		words := make([]string, 0, len(lines))
+
+		for _, line := range lines {
+			if word := strings.TrimSpace(line); word != "" {
+				words = append(words, word)
+			}
+		}
+
+		return words, nil
+	case "text":
+		return utils.ReadWords(reader)
+	default:
+		return nil, errMode
+	}
+}
+
+// Find метод, возвращающий множества анаграмм словаря, в которых не меньше --min-size слов, упорядоченные в
+// соответствии с опцией --sort-by. Возвращает ошибку контекста, если параллельный поиск прерван отменой ctx
+func (ac *AnagramClient) Find(ctx context.Context) ([]AnagramSet, error) {
+	sets, err := ac.findSets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return filterSets(sets, ac.flags.minSize, ac.flags.sortBy), nil
+}
+
+// filterSets возвращает новый слайс множеств анаграмм из sets, в которых не меньше minSize слов, упорядоченный по
+// sortBy: key - по ключу, size - по убыванию размера, first - в исходном порядке
+func filterSets(sets []AnagramSet, minSize int, sortBy string) []AnagramSet {
+	result := make([]AnagramSet, 0, len(sets))
+
+	for _, set := range sets {
+		if len(set.Words) >= minSize {
+			result = append(result, set)
+		}
+	}
+
+	// сортировка first, множества уже упорядочены по первому появлению
+	if sortBy == "first" {
+		return result
+	}
+
+	// при равном размере множества упорядочиваются по ключу
+	slices.SortFunc(result, func(set1, set2 AnagramSet) int {
+		if sortBy == "size" && len(set1.Words) != len(set2.Words) {
+			return cmp.Compare(len(set2.Words), len(set1.Words))
+		}
+
+		return cmp.Compare(set1.Key, set2.Key)
+	})
+
+	return result
+}
+
+// findSets метод, возвращающий множества анаграмм словаря в порядке первого появления, учитывая опции --distance и
+// --workers
+func (ac *AnagramClient) findSets(ctx context.Context) ([]AnagramSet, error) {
+	if ac.flags.distance > 0 {
+		return FindNearAnagramSets(ac.data, ac.normalizer, ac.flags.distance), nil
+	}
+
+	if ac.flags.workers == 1 {
+		return FindOrderedAnagramSets(ac.data, ac.normalizer), nil
+	}
+
+	// при завершении поиска с ошибкой горутина отправки слов останавливается отменой контекста
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	words := make(chan string, parallelBatchSize)
+
+	go func() {
+		defer close(words)
 
-	// проходимся циклом по words, ищем анаграмы, записываем их в мапу, где ключи - слова, отсортированные посимвольно
-	// в нижнем регистре
+		for _, word := range ac.data {
+			select {
+			case words <- word:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return FindAnagramSetsStream(ctx, words, ParallelOptions{
+		Workers:    ac.flags.workers,
+		Normalizer: ac.normalizer,
+	})
+}
+
+// Stats метод, возвращающий статистику словаря и найденных множеств анаграмм
+func (ac *AnagramClient) Stats(sets []AnagramSet) AnagramStats {
+	return dictionaryStats(ac.data, ac.normalizer, sets)
+}
+
+// dictionaryStats возвращает статистику словаря words, слова которого приводятся к виду для сравнения normalizer, и
+// найденных в нем множеств анаграмм sets
+func dictionaryStats(words []string, normalizer *Normalizer, sets []AnagramSet) AnagramStats {
+	stats := AnagramStats{
+		Words: len(words),
+		Sets:  len(sets),
+	}
+
+	unique := make(map[string]struct{})
 	for _, word := range words {
-		sortedWord := SortWord(word)
+		unique[normalizer.Normalize(word)] = struct{}{}
+	}
+
+	stats.Unique = len(unique)
+
+	for _, set := range sets {
+		stats.Anagrams += len(set.Words)
+		stats.LargestSet = max(stats.LargestSet, len(set.Words))
+	}
+
+	return stats
+}
+
+// Write метод для записи множеств анаграмм в writer в формате, заданном опцией --format
+func (ac *AnagramClient) Write(writer io.Writer, sets []AnagramSet) error {
+	bufferedWriter := bufio.NewWriter(writer)
+
+	var err error
+
+	switch ac.flags.format {
+	case "json":
+		encoder := json.NewEncoder(bufferedWriter)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(sets)
+	case "csv":
+		csvWriter := csv.NewWriter(bufferedWriter)
+
+		// учитывание опции --distance, расстояния слов от ключа записываются в отдельную колонку
+		header := []string{"key", "size", "words"}
+		if ac.flags.distance > 0 {
+			header = append(header, "distances")
+		}
+
+		err = csvWriter.Write(header)
+
+		for _, set := range sets {
+			if err != nil {
+				break
+			}
+
+			record := []string{set.Key, strconv.Itoa(len(set.Words)), strings.Join(set.Words, " ")}
+			if ac.flags.distance > 0 {
+				record = append(record, joinInts(set.Distances))
+			}
+
+			err = csvWriter.Write(record)
+		}
+
+		csvWriter.Flush()
+		err = errors.Join(err, csvWriter.Error())
+	default:
+		for _, set := range sets {
+			if err != nil {
+				break
+			}
+
+			_, err = fmt.Fprintf(bufferedWriter, "%s: %s\n", set.Key, setWords(set))
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return bufferedWriter.Flush()
+}
+
+// setWords возвращает слова множества через пробел, после слов, отличающихся от ключа, в скобках указывается
+// расстояние от ключа
+func setWords(set AnagramSet) string {
+	words := make([]string, len(set.Words))
+
+	for i, word := range set.Words {
+		words[i] = word
+
+		if i < len(set.Distances) && set.Distances[i] > 0 {
+			words[i] = fmt.Sprintf("%s(%d)", word, set.Distances[i])
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+// joinInts возвращает числа через пробел
+func joinInts(numbers []int) string {
+	texts := make([]string, len(numbers))
+	for i, number := range numbers {
+		texts[i] = strconv.Itoa(number)
+	}
+
+	return strings.Join(texts, " ")
+}
+
+// BuildIndex метод, строящий индекс анаграмм словаря и записывающий его в файл path
+func (ac *AnagramClient) BuildIndex(path string) error {
+	outputFile, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	_, err = NewNormalizedAnagramIndex(ac.data, ac.normalizer).WriteTo(outputFile)
+	if err != nil {
+		_ = outputFile.Close()
+		return err
+	}
+
+	return outputFile.Close()
+}
+
+// Query метод, читающий слова-запросы из входных файлов построчно и записывающий в writer анаграммы каждого слова
+// из индекса сразу после чтения запроса, что позволяет использовать утилиту интерактивно. Формат вывода задается
+// опцией --format, для json каждый ответ записывается отдельной строкой (JSON Lines)
+func (ac *AnagramClient) Query(writer io.Writer) error {
+	defer ac.closeInputs()
+
+	bufferedWriter := bufio.NewWriter(writer)
+	csvWriter := csv.NewWriter(bufferedWriter)
+
+	if ac.flags.format == "csv" {
+		err := csvWriter.Write([]string{"key", "size", "words"})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, inputFile := range ac.args.inputFiles {
+		scanner := bufio.NewScanner(inputFile)
+
+		for scanner.Scan() {
+			word := strings.TrimSpace(scanner.Text())
+			if word == "" {
+				continue
+			}
+
+			anagrams, err := ac.index.Lookup(word)
+			if err != nil {
+				return err
+			}
+
+			err = ac.writeQuery(bufferedWriter, csvWriter, word, anagrams)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+
+	return bufferedWriter.Flush()
+}
+
+// writeQuery метод для записи ответа на запрос в writer в формате, заданном опцией --format, и сброса буфера
+func (ac *AnagramClient) writeQuery(writer *bufio.Writer, csvWriter *csv.Writer, word string, anagrams []string) error {
+	var err error
+
+	switch ac.flags.format {
+	case "json":
+		err = json.NewEncoder(writer).Encode(struct {
+			Key   string   `json:"key"`
+			Words []string `json:"words"`
+		}{Key: word, Words: anagrams})
+	case "csv":
+		err = csvWriter.Write([]string{word, strconv.Itoa(len(anagrams)), strings.Join(anagrams, " ")})
+		csvWriter.Flush()
+		err = errors.Join(err, csvWriter.Error())
+	default:
+		_, err = fmt.Fprintf(writer, "%s: %s\n", word, strings.Join(anagrams, " "))
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return writer.Flush()
+}
+
+// closeInputs метод, закрывающий входные файлы
+func (ac *AnagramClient) closeInputs() {
+	for _, inputFile := range ac.args.inputFiles {
+		_ = inputFile.Close()
+	}
+}
+
+// WriteStats метод для записи статистики в writer
+func (ac *AnagramClient) WriteStats(writer io.Writer, stats AnagramStats) error {
+	return utils.WriteData(writer,
+		fmt.Sprintf("words: %d", stats.Words),
+		fmt.Sprintf("unique: %d", stats.Unique),
+		fmt.Sprintf("sets: %d", stats.Sets),
+		fmt.Sprintf("anagrams: %d", stats.Anagrams),
+		fmt.Sprintf("largest set: %d", stats.LargestSet),
+	)
+}
+
+// NewLexicon принимает на вход слайс строк и normalizer (nil - только нижний регистр), возвращает словарь для поиска
+// палиндромов и лестниц слов, состоящий из уникальных нормализованных слов
+func NewLexicon(words []string, normalizer *Normalizer) *lexicon.Lexicon {
+	normalized := make([]string, len(words))
+	for i, word := range words {
+		normalized[i] = normalizer.Normalize(word)
+	}
+
+	// нормализованные слова уже приведены к регистру с учетом языка (опция --locale), повторно регистр не меняется
+	return lexicon.New(normalized, normalizer.Normalize)
+}
+
+// WriteLexicon метод для записи в writer палиндромов словаря (опция --palindromes) и лестницы слов (опция --ladder),
+// слова лестницы разделяются стрелками
+func (ac *AnagramClient) WriteLexicon(writer io.Writer) error {
+	words := NewLexicon(ac.data, ac.normalizer)
+
+	if ac.flags.palindromes {
+		err := utils.WriteData(writer, words.Palindromes(2)...)
+		if err != nil {
+			return err
+		}
+	}
+
+	if ac.flags.ladder != "" {
+		from, to, _ := strings.Cut(ac.flags.ladder, ",")
+
+		ladder, err := words.Ladder(strings.TrimSpace(from), strings.TrimSpace(to))
+		if err != nil {
+			return err
+		}
 
-		anagramSets[sortedWord] = append(anagramSets[sortedWord], word)
+		return utils.WriteData(writer, strings.Join(ladder, " -> "))
 	}
 
-	// меняем ключи мапы с отсортированных посимвольно слов на первые встретившееся
-	for key, value := range keys {
-		anagramSets[value] = anagramSets[key]
-		delete(anagramSets, key)
+	return nil
+}
+
+// Serve метод, запускающий HTTP-сервер поиска анаграмм на адресе --serve со словарем из входных файлов до получения
+// сигнала о завершении работы
+func (ac *AnagramClient) Serve() error {
+	server := NewAnagramServer(ServerOptions{
+		Mode:        ac.flags.mode,
+		Normalizer:  ac.normalizer,
+		CacheSize:   serverCacheSize,
+		MaxBodySize: serverMaxBodySize,
+	})
+
+	if len(ac.data) > 0 {
+		server.Load(ac.data)
 	}
 
-	// убираем пары ключ-значение, где в значении слайс длинной небольше 1, иначе сортируем его
-	for sortedWord, wordSet := range anagramSets {
-		if len(wordSet) <= 1 {
-			delete(anagramSets, sortedWord)
-		} else {
-			sort.Strings(wordSet)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return server.ListenAndServe(ctx, ac.flags.serve)
+}
+
+// Start метод запуска утилиты
+func (ac *AnagramClient) Start() error {
+	// учитывание опции --index
+	if ac.index != nil {
+		defer ac.index.Close()
+
+		return ac.Query(os.Stdout)
+	}
+
+	// учитывание опции --serve
+	if ac.flags.serve != "" {
+		return ac.Serve()
+	}
+
+	// учитывание опций --palindromes и --ladder
+	if ac.flags.palindromes || ac.flags.ladder != "" {
+		return ac.WriteLexicon(os.Stdout)
+	}
+
+	// поиск множеств анаграмм прерывается по сигналу
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// учитывание опции --build-index, вместо вывода множеств анаграмм словарь сохраняется в виде индекса, множества
+	// с учетом опций --min-size и --sort-by находятся только для статистики
+	if ac.flags.buildIndex != "" {
+		err := ac.BuildIndex(ac.flags.buildIndex)
+		if err != nil || !ac.flags.stats {
+			return err
 		}
+
+		sets, err := ac.Find(ctx)
+		if err != nil {
+			return err
+		}
+
+		return ac.WriteStats(os.Stderr, ac.Stats(sets))
+	}
+
+	sets, err := ac.Find(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = ac.Write(os.Stdout, sets)
+	if err != nil {
+		return err
+	}
+
+	// учитывание опции --stats, статистика выводится в os.Stderr, чтобы не смешиваться с результатом
+	if ac.flags.stats {
+		return ac.WriteStats(os.Stderr, ac.Stats(sets))
 	}
 
-	return anagramSets
+	return nil
+}
+
+func main() {
+	var anagramClient *AnagramClient
+	var err error
+
+	// создание объекта структуры AnagramClient, в случае ошибки - её вывод и выход из программы с кодом ошибки 1
+	anagramClient, err = NewAnagramClient()
+	if err != nil {
+		fmt.Printf("%q\n", err)
+		os.Exit(1)
+	}
+
+	// запуск утилиты, в случае ошибки - её вывод и выход из программы с кодом ошибки 1
+	err = anagramClient.Start()
+	if err != nil {
+		fmt.Printf("%q\n", err)
+		os.Exit(1)
+	}
 }
@@ -1,8 +1,27 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"testing/iotest"
+	"wb-level-2/develop/dev04/lexicon"
+	"wb-level-2/develop/dev04/utils"
 )
 
 func TestFindAnagramSets001(t *testing.T) {
@@ -70,3 +89,1721 @@ func TestFindAnagramSets004(t *testing.T) {
 		t.Errorf("Result was incorrect, got: %s, want: %s.", actual, expected)
 	}
 }
+
+func TestFindAnagramSets005(t *testing.T) {
+	input := []string{"кот", "ток", "окт", "сон", "нос"}
+	expected := map[string][]string{
+		"кот": {"кот", "окт", "ток"},
+		"сон": {"нос", "сон"},
+	}
+
+	actual := FindAnagramSets(input)
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Result was incorrect, got: %s, want: %s.", actual, expected)
+	}
+}
+
+func resetArgs(args []string) {
+	os.Args = append([]string{"testArgs"}, args...)
+}
+
+func TestAnagramFlags_Parse(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		flags AnagramFlags
+	}{
+		{
+			name:  "Default flags",
+			args:  []string{},
+			flags: AnagramFlags{mode: "lines", format: "text", minSize: 2, sortBy: "key", workers: 1},
+		},
+		{
+			name: "All flags",
+			args: []string{
+				"--mode", "text", "--format", "json", "--min-size", "3", "--sort-by", "size",
+				"--stats", "--workers", "4", "--distance", "1",
+			},
+			flags: AnagramFlags{
+				mode:     "text",
+				format:   "json",
+				minSize:  3,
+				sortBy:   "size",
+				stats:    true,
+				workers:  4,
+				distance: 1,
+			},
+		},
+		{
+			name: "Normalization flags",
+			args: []string{"--normalize", "nfc", "--fold-diacritics", "--locale", "tr", "--ignore-punct"},
+			flags: AnagramFlags{
+				mode:    "lines",
+				format:  "text",
+				minSize: 2,
+				sortBy:  "key",
+				workers: 1,
+				normalize: NormalizeOptions{
+					Form:              "nfc",
+					FoldDiacritics:    true,
+					Locale:            "tr",
+					IgnorePunctuation: true,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flag.CommandLine = flag.NewFlagSet(tt.name, flag.ContinueOnError)
+			af := AnagramFlags{}
+
+			resetArgs(tt.args)
+			af.Parse()
+
+			if !reflect.DeepEqual(af, tt.flags) {
+				t.Errorf("AnagramFlags.Parse() got = %v, want %v", af, tt.flags)
+			}
+		})
+	}
+}
+
+func TestNewAnagramClient(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "words.txt")
+
+	err := os.WriteFile(name, []byte("Пятак, пятка и тяпка.\nЛисток - слиток, столик!\n"), 0o644)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected []string
+		err      error
+	}{
+		{
+			name:     "Lines mode",
+			args:     []string{name},
+			expected: []string{"Пятак, пятка и тяпка.", "Листок - слиток, столик!"},
+		},
+		{
+			name:     "Text mode",
+			args:     []string{"--mode", "text", name},
+			expected: []string{"Пятак", "пятка", "и", "тяпка", "Листок", "слиток", "столик"},
+		},
+		{name: "Invalid mode", args: []string{"--mode", "csv", name}, err: errMode},
+		{name: "Invalid format", args: []string{"--format", "xml", name}, err: errFormat},
+		{name: "Invalid sort order", args: []string{"--sort-by", "length", name}, err: errSortBy},
+		{name: "Negative distance", args: []string{"--distance", "-1", name}, err: errDistance},
+		{name: "Too large distance", args: []string{"--distance", "4", name}, err: errDistance},
+		{name: "Invalid ladder", args: []string{"--ladder", "cold", name}, err: errLadder},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flag.CommandLine = flag.NewFlagSet(tt.name, flag.ContinueOnError)
+			resetArgs(tt.args)
+
+			ac, err := NewAnagramClient()
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+
+			if err == nil && !reflect.DeepEqual(ac.data, tt.expected) {
+				t.Errorf("got %q, want %q", ac.data, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnagramClient_Find(t *testing.T) {
+	data := []string{"пятак", "листок", "пятка", "слиток", "столик", "тяпка", "кот", "ток", "окт", "отк", "сон", "нос"}
+
+	tests := []struct {
+		name     string
+		flags    AnagramFlags
+		expected []string
+	}{
+		{
+			name:     "Sort by key",
+			flags:    AnagramFlags{minSize: 2, sortBy: "key"},
+			expected: []string{"кот", "листок", "пятак", "сон"},
+		},
+		{
+			name:     "Sort by size with min size",
+			flags:    AnagramFlags{minSize: 3, sortBy: "size"},
+			expected: []string{"кот", "листок", "пятак"},
+		},
+		{
+			name:     "Sort by first appearance",
+			flags:    AnagramFlags{minSize: 2, sortBy: "first"},
+			expected: []string{"пятак", "листок", "кот", "сон"},
+		},
+		{
+			name:     "Parallel grouping",
+			flags:    AnagramFlags{minSize: 2, sortBy: "first", workers: 3},
+			expected: []string{"пятак", "листок", "кот", "сон"},
+		},
+	}
+
+	sets := map[string]AnagramSet{
+		"кот": {
+			Key:        "кот",
+			Words:      []string{"кот", "окт", "отк", "ток"},
+			FirstIndex: 6,
+			Forms:      []string{"кот", "ток", "окт", "отк"},
+		},
+		"листок": {
+			Key:        "листок",
+			Words:      []string{"листок", "слиток", "столик"},
+			FirstIndex: 1,
+			Forms:      []string{"листок", "слиток", "столик"},
+		},
+		"пятак": {
+			Key:        "пятак",
+			Words:      []string{"пятак", "пятка", "тяпка"},
+			FirstIndex: 0,
+			Forms:      []string{"пятак", "пятка", "тяпка"},
+		},
+		"сон": {Key: "сон", Words: []string{"нос", "сон"}, FirstIndex: 10, Forms: []string{"сон", "нос"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ac := &AnagramClient{flags: tt.flags, data: data}
+
+			expected := make([]AnagramSet, 0, len(tt.expected))
+			for _, key := range tt.expected {
+				expected = append(expected, sets[key])
+			}
+
+			result, err := ac.Find(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(result, expected) {
+				t.Errorf("got %v, want %v", result, expected)
+			}
+		})
+	}
+}
+
+func TestAnagramClient_Write(t *testing.T) {
+	sets := []AnagramSet{
+		{
+			Key:        "Пятак",
+			Words:      []string{"пятак", "пятка", "тяпка"},
+			FirstIndex: 0,
+			Forms:      []string{"Пятак", "пятка", "тяпка"},
+		},
+		{Key: "сон", Words: []string{"нос", "сон"}, FirstIndex: 3, Forms: []string{"сон", "нос"}},
+	}
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{
+			format:   "text",
+			expected: "Пятак: пятак пятка тяпка\nсон: нос сон\n",
+		},
+		{
+			format:   "csv",
+			expected: "key,size,words\nПятак,3,пятак пятка тяпка\nсон,2,нос сон\n",
+		},
+		{
+			format: "json",
+			expected: `[
+  {
+    "key": "Пятак",
+    "words": [
+      "пятак",
+      "пятка",
+      "тяпка"
+    ],
+    "first_index": 0,
+    "forms": [
+      "Пятак",
+      "пятка",
+      "тяпка"
+    ]
+  },
+  {
+    "key": "сон",
+    "words": [
+      "нос",
+      "сон"
+    ],
+    "first_index": 3,
+    "forms": [
+      "сон",
+      "нос"
+    ]
+  }
+]
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			ac := &AnagramClient{flags: AnagramFlags{format: tt.format}}
+
+			var output strings.Builder
+
+			err := ac.Write(&output, sets)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if output.String() != tt.expected {
+				t.Errorf("got %q, want %q", output.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnagramClient_WriteDistances(t *testing.T) {
+	sets := []AnagramSet{
+		{
+			Key:        "пятак",
+			Words:      []string{"пятак", "пятаки", "тяпка"},
+			FirstIndex: 0,
+			Forms:      []string{"пятак", "тяпка", "пятаки"},
+			Distances:  []int{0, 1, 0},
+		},
+	}
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{format: "text", expected: "пятак: пятак пятаки(1) тяпка\n"},
+		{format: "csv", expected: "key,size,words,distances\nпятак,3,пятак пятаки тяпка,0 1 0\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			ac := &AnagramClient{flags: AnagramFlags{format: tt.format, distance: 1}}
+
+			var output strings.Builder
+
+			err := ac.Write(&output, sets)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if output.String() != tt.expected {
+				t.Errorf("got %q, want %q", output.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnagramClient_FindCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// слов больше, чем помещается в буфер канала, поэтому отправка слов завершается только отменой контекста
+	data := make([]string, 0, 16*parallelBatchSize)
+	for len(data) < cap(data) {
+		data = append(data, "сон", "нос")
+	}
+
+	ac := &AnagramClient{flags: AnagramFlags{minSize: 2, sortBy: "key", workers: 2}, data: data}
+
+	_, err := ac.Find(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestAnagramClient_Stats(t *testing.T) {
+	ac := &AnagramClient{
+		flags: AnagramFlags{minSize: 2, sortBy: "key"},
+		data:  []string{"Сон", "нос", "сон", "кот", "ток", "окт", "лес"},
+	}
+
+	expected := AnagramStats{Words: 7, Unique: 6, Sets: 2, Anagrams: 5, LargestSet: 3}
+
+	sets, err := ac.Find(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result := ac.Stats(sets)
+
+	if result != expected {
+		t.Errorf("got %+v, want %+v", result, expected)
+	}
+
+	var output strings.Builder
+
+	err = ac.WriteStats(&output, result)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if output.String() != "words: 7\nunique: 6\nsets: 2\nanagrams: 5\nlargest set: 3\n" {
+		t.Errorf("got %q", output.String())
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{input: "Пятак, пятка и тяпка.", expected: []string{"Пятак", "пятка", "и", "тяпка"}},
+		{input: "don't stop e.g. here", expected: []string{"don't", "stop", "e.g", "here"}},
+		{input: "pi = 3,14; n_1 'quoted'", expected: []string{"pi", "3,14", "n_1", "quoted"}},
+		{input: "café — naïve", expected: []string{"café", "naïve"}},
+		{input: " ... ", expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result := utils.Tokenize(tt.input)
+
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("got %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+// mustLookup возвращает анаграммы слова из индекса, завершая тест при ошибке
+func mustLookup(t *testing.T, ai *AnagramIndex, word string) []string {
+	t.Helper()
+
+	result, err := ai.Lookup(word)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return result
+}
+
+func TestAnagramIndex(t *testing.T) {
+	ai := NewAnagramIndex([]string{"Пятак", "пятка", "тяпка", "листок", "слиток", "кот", "пятак"})
+
+	t.Run("Lookup", func(t *testing.T) {
+		tests := []struct {
+			word     string
+			expected []string
+		}{
+			{word: "ПЯТАК", expected: []string{"пятка", "тяпка"}},
+			{word: "катяп", expected: []string{"пятак", "пятка", "тяпка"}},
+			{word: "столик", expected: []string{"листок", "слиток"}},
+			{word: "кот", expected: []string{}},
+			{word: "сон", expected: []string{}},
+		}
+
+		for _, tt := range tests {
+			result := mustLookup(t, ai, tt.word)
+
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Lookup(%q) got %v, want %v", tt.word, result, tt.expected)
+			}
+		}
+	})
+
+	t.Run("Add and remove", func(t *testing.T) {
+		ai := NewAnagramIndex([]string{"кот"})
+
+		if !ai.Add("Ток") || ai.Add("ток") || !ai.Contains("ТОК") || ai.Len() != 2 {
+			t.Errorf("unexpected add result")
+		}
+
+		if !ai.Remove("кот") || ai.Remove("кот") || ai.Contains("кот") || ai.Len() != 1 {
+			t.Errorf("unexpected remove result")
+		}
+
+		if !reflect.DeepEqual(mustLookup(t, ai, "окт"), []string{"ток"}) {
+			t.Errorf("got %v, want [ток]", mustLookup(t, ai, "окт"))
+		}
+	})
+
+	t.Run("Save and load", func(t *testing.T) {
+		dir := t.TempDir()
+
+		saveIndex := func(index *AnagramIndex, path string) {
+			file, err := os.Create(path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			defer file.Close()
+
+			_, err = index.WriteTo(file)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		path := filepath.Join(dir, "index.bin")
+		saveIndex(ai, path)
+
+		loaded, err := LoadAnagramIndex(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if loaded.Len() != ai.Len() || !reflect.DeepEqual(mustLookup(t, loaded, "пятак"), mustLookup(t, ai, "пятак")) {
+			t.Errorf("loaded index differs: %d words, %v", loaded.Len(), mustLookup(t, loaded, "пятак"))
+		}
+
+		loaded.Add("столик")
+		loaded.Remove("кот")
+
+		if !reflect.DeepEqual(mustLookup(t, loaded, "листок"), []string{"слиток", "столик"}) || loaded.Contains("кот") {
+			t.Errorf("got %v after add and remove", mustLookup(t, loaded, "листок"))
+		}
+
+		// измененный индекс сохраняется в другой файл, так как исходный файл отображен в память
+		changedPath := filepath.Join(dir, "changed.bin")
+		saveIndex(loaded, changedPath)
+
+		err = loaded.Close()
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if _, err := loaded.Lookup("тяпка"); !errors.Is(err, ErrIndexClosed) {
+			t.Errorf("got error %v after close, want %v", err, ErrIndexClosed)
+		}
+
+		err = loaded.Range(func(string, []string) bool {
+			return true
+		})
+		if !errors.Is(err, ErrIndexClosed) {
+			t.Errorf("got error %v after close, want %v", err, ErrIndexClosed)
+		}
+
+		if loaded.Contains("тяпка") || loaded.Add("кот") || loaded.Remove("пятак") {
+			t.Errorf("closed index is modified")
+		}
+
+		reloaded, err := LoadAnagramIndex(changedPath)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		defer reloaded.Close()
+
+		if reloaded.Len() != 6 || !reflect.DeepEqual(mustLookup(t, reloaded, "листок"), []string{"слиток", "столик"}) {
+			t.Errorf("reloaded index differs: %d words, %v", reloaded.Len(), mustLookup(t, reloaded, "листок"))
+		}
+	})
+
+	t.Run("Corrupt index", func(t *testing.T) {
+		for _, data := range []string{"", "ANAGIDX1", "NOTINDEX\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00"} {
+			path := filepath.Join(t.TempDir(), "index.bin")
+
+			err := os.WriteFile(path, []byte(data), 0o644)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			_, err = LoadAnagramIndex(path)
+			if !errors.Is(err, ErrCorruptIndex) {
+				t.Errorf("got error %v, want %v", err, ErrCorruptIndex)
+			}
+		}
+	})
+}
+
+func TestAnagramClient_Query(t *testing.T) {
+	dir := t.TempDir()
+	indexPath := filepath.Join(dir, "index.bin")
+	queryPath := filepath.Join(dir, "queries.txt")
+
+	ac := &AnagramClient{data: []string{"пятак", "пятка", "тяпка", "кот"}}
+
+	err := ac.BuildIndex(indexPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = os.WriteFile(queryPath, []byte("Пятак\n\nток\n"), 0o644)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{format: "text", expected: "Пятак: пятка тяпка\nток: кот\n"},
+		{
+			format:   "json",
+			expected: `{"key":"Пятак","words":["пятка","тяпка"]}` + "\n" + `{"key":"ток","words":["кот"]}` + "\n",
+		},
+		{format: "csv", expected: "key,size,words\nПятак,2,пятка тяпка\nток,1,кот\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			flag.CommandLine = flag.NewFlagSet(tt.format, flag.ContinueOnError)
+			resetArgs([]string{"--index", indexPath, "--format", tt.format, queryPath})
+
+			ac, err := NewAnagramClient()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			defer ac.index.Close()
+
+			var output strings.Builder
+
+			err = ac.Query(&output)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if output.String() != tt.expected {
+				t.Errorf("got %q, want %q", output.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnagramIndex_SubAnagrams(t *testing.T) {
+	ai := NewAnagramIndex([]string{"кот", "ток", "кто", "то", "сок", "котик", "и", "кит", "тик"})
+
+	tests := []struct {
+		name     string
+		letters  string
+		limit    int
+		expected []string
+	}{
+		{
+			name:     "All sub-anagrams",
+			letters:  "КОТ и!",
+			expected: []string{"кит", "тик", "кот", "кто", "ток", "то", "и"},
+		},
+		{
+			name:     "Letters used once",
+			letters:  "тиик",
+			expected: []string{"кит", "тик", "и"},
+		},
+		{
+			name:     "Limit",
+			letters:  "котик",
+			limit:    2,
+			expected: []string{"котик", "кит"},
+		},
+		{
+			name:     "No letters",
+			letters:  "",
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ai.SubAnagrams(context.Background(), tt.letters, tt.limit)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("got %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnagramIndex_PhraseAnagrams(t *testing.T) {
+	ai := NewAnagramIndex([]string{"dormitory", "dirty", "room", "moor", "dirt", "y", "or", "my", "tidy", "a"})
+
+	tests := []struct {
+		name     string
+		phrase   string
+		maxWords int
+		limit    int
+		expected [][]string
+	}{
+		{
+			name:     "Two words",
+			phrase:   "Dormitory",
+			maxWords: 2,
+			expected: [][]string{{"dormitory"}, {"dirty", "moor"}, {"dirty", "room"}},
+		},
+		{
+			name:     "Three words",
+			phrase:   "dormitory",
+			maxWords: 3,
+			expected: [][]string{
+				{"dormitory"},
+				{"dirty", "moor"},
+				{"dirty", "room"},
+				{"dirt", "moor", "y"},
+				{"dirt", "room", "y"},
+			},
+		},
+		{
+			name:     "Repeated words are not permuted",
+			phrase:   "a a",
+			maxWords: 2,
+			expected: [][]string{{"a", "a"}},
+		},
+		{
+			name:     "Limit",
+			phrase:   "dirty room",
+			maxWords: 3,
+			limit:    2,
+			expected: [][]string{{"dormitory"}, {"dirty", "moor"}},
+		},
+		{
+			name:     "No anagrams",
+			phrase:   "xyz",
+			maxWords: 3,
+			expected: [][]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ai.PhraseAnagrams(context.Background(), tt.phrase, tt.maxWords, tt.limit)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("got %v, want %v", result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("Cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := ai.PhraseAnagrams(ctx, "dormitory", 3, 0)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got error %v, want %v", err, context.Canceled)
+		}
+
+		_, err = ai.SubAnagrams(ctx, "dormitory", 0)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got error %v, want %v", err, context.Canceled)
+		}
+	})
+}
+
+// findAnagramSetsReference прежняя реализация FindAnagramSets с сортировкой символов через strings.Split для
+// сравнения результатов и производительности
+func findAnagramSetsReference(words []string) map[string][]string {
+	sortWord := func(word string) string {
+		wordArr := strings.Split(word, "")
+		sort.Strings(wordArr)
+
+		return strings.Join(wordArr, "")
+	}
+
+	anagramSets := make(map[string][]string)
+	keys := make(map[string]string)
+
+	for _, word := range words {
+		sortedLowerWord := sortWord(strings.ToLower(word))
+
+		if _, exists := keys[sortedLowerWord]; !exists {
+			keys[sortedLowerWord] = word
+		}
+	}
+
+	for _, word := range ToLowerUnique(words) {
+		sortedWord := sortWord(word)
+		anagramSets[sortedWord] = append(anagramSets[sortedWord], word)
+	}
+
+	result := make(map[string][]string)
+
+	for sortedWord, wordSet := range anagramSets {
+		if len(wordSet) > 1 {
+			sort.Strings(wordSet)
+			result[keys[sortedWord]] = wordSet
+		}
+	}
+
+	return result
+}
+
+// generateWords возвращает count случайных слов из небольшого алфавита, чтобы среди них было много анаграмм
+func generateWords(count int) []string {
+	random := rand.New(rand.NewSource(42))
+	alphabet := []rune("аеклостпяАОТ")
+	words := make([]string, count)
+
+	for i := range words {
+		word := make([]rune, 2+random.Intn(5))
+		for j := range word {
+			word[j] = alphabet[random.Intn(len(alphabet))]
+		}
+
+		words[i] = string(word)
+	}
+
+	return words
+}
+
+func TestFindAnagramSets_MatchesReference(t *testing.T) {
+	words := generateWords(20000)
+
+	if !reflect.DeepEqual(FindAnagramSets(words), findAnagramSetsReference(words)) {
+		t.Errorf("FindAnagramSets differs from the reference implementation")
+	}
+}
+
+func TestSortWord(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "тяпка", expected: "акптя"},
+		{input: "Ёлка", expected: "Ёакл"},
+		{input: "", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if result := SortWord(tt.input); result != tt.expected {
+				t.Errorf("got %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsAnagram(t *testing.T) {
+	tests := []struct {
+		word1, word2 string
+		expected     bool
+	}{
+		{word1: "пятак", word2: "тяпка", expected: true},
+		{word1: "аab", word2: "abа", expected: true},
+		{word1: "aab", word2: "abb", expected: false},
+		{word1: "ab", word2: "abc", expected: false},
+		{word1: "я", word2: "ab", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word1+" "+tt.word2, func(t *testing.T) {
+			if result := isAnagram(tt.word1, tt.word2); result != tt.expected {
+				t.Errorf("got %v, want %v", result, tt.expected)
+			}
+
+			if tt.expected && signatureHash(tt.word1) != signatureHash(tt.word2) {
+				t.Errorf("anagrams have different hashes")
+			}
+		})
+	}
+}
+
+func BenchmarkFindAnagramSets(b *testing.B) {
+	words := generateWords(100000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		FindAnagramSets(words)
+	}
+}
+
+func BenchmarkFindAnagramSetsReference(b *testing.B) {
+	words := generateWords(100000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		findAnagramSetsReference(words)
+	}
+}
+
+func BenchmarkSignatureHash(b *testing.B) {
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		signatureHash("достопримечательность")
+	}
+}
+
+func BenchmarkSortWord(b *testing.B) {
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		SortWord("достопримечательность")
+	}
+}
+
+func TestNormalizer_Normalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  NormalizeOptions
+		input    string
+		expected string
+	}{
+		{name: "Nil normalizer", input: "ЁЛКА", expected: "ёлка"},
+		{name: "Fold diacritics", options: NormalizeOptions{FoldDiacritics: true}, input: "Ёлка", expected: "елка"},
+		{
+			name:     "Keep short i",
+			options:  NormalizeOptions{FoldDiacritics: true},
+			input:    "Йод caf\u00e9",
+			expected: "йод cafe",
+		},
+		{name: "NFC", options: NormalizeOptions{Form: "nfc"}, input: "cafe\u0301", expected: "caf\u00e9"},
+		{name: "NFD", options: NormalizeOptions{Form: "NFD"}, input: "caf\u00e9", expected: "cafe\u0301"},
+		{name: "Turkish locale", options: NormalizeOptions{Locale: "tr"}, input: "IŞIK İz", expected: "ışık iz"},
+		{
+			name:     "Turkish locale with folding",
+			options:  NormalizeOptions{Locale: "tr", FoldDiacritics: true},
+			input:    "İstanbul IŞIK",
+			expected: "istanbul ısık",
+		},
+		{
+			name:     "Ignore punctuation",
+			options:  NormalizeOptions{IgnorePunctuation: true},
+			input:    "Dirty room, don't!",
+			expected: "dirtyroomdont",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var normalizer *Normalizer
+
+			if tt.options != (NormalizeOptions{}) {
+				var err error
+
+				normalizer, err = NewNormalizer(tt.options)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			if result := normalizer.Normalize(tt.input); result != tt.expected {
+				t.Errorf("got %q, want %q", result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("Invalid options", func(t *testing.T) {
+		_, err := NewNormalizer(NormalizeOptions{Form: "nfkc"})
+		if !errors.Is(err, ErrUnknownForm) {
+			t.Errorf("got error %v, want %v", err, ErrUnknownForm)
+		}
+
+		_, err = NewNormalizer(NormalizeOptions{Locale: "not a locale"})
+		if err == nil {
+			t.Errorf("expected error for invalid locale")
+		}
+	})
+}
+
+func TestFindNormalizedAnagramSets(t *testing.T) {
+	normalizer, err := NewNormalizer(NormalizeOptions{Form: "nfc", FoldDiacritics: true, IgnorePunctuation: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	input := []string{"Ёлка", "лекА", "кале", "caf\u00e9", "face\u0301", "dirty room", "Dormitory!"}
+	expected := map[string][]string{
+		"Ёлка":       {"елка", "кале", "лека"},
+		"caf\u00e9":  {"cafe", "face"},
+		"dirty room": {"dirtyroom", "dormitory"},
+	}
+
+	actual := FindNormalizedAnagramSets(input, normalizer)
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("got %v, want %v", actual, expected)
+	}
+
+	t.Run("Decomposed clusters are kept whole", func(t *testing.T) {
+		normalizer, err := NewNormalizer(NormalizeOptions{Form: "nfd"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// в "éa" и "áe" одинаковые символы, но разные графемные кластеры
+		actual := FindNormalizedAnagramSets([]string{"\u00e9a", "\u00e1e", "a\u00e9"}, normalizer)
+		expected := map[string][]string{"\u00e9a": {"ae\u0301", "e\u0301a"}}
+
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("got %q, want %q", actual, expected)
+		}
+
+		if SortWord("e\u0301a") != "ae\u0301" {
+			t.Errorf("got %q, want %q", SortWord("e\u0301a"), "ae\u0301")
+		}
+	})
+}
+
+func TestAnagramIndex_Normalizer(t *testing.T) {
+	normalizer, err := NewNormalizer(NormalizeOptions{FoldDiacritics: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ai := NewNormalizedAnagramIndex([]string{"ёлка", "кале"}, normalizer)
+
+	if !reflect.DeepEqual(mustLookup(t, ai, "ЛЁКА"), []string{"елка", "кале"}) {
+		t.Errorf("got %v, want [елка кале]", mustLookup(t, ai, "ЛЁКА"))
+	}
+
+	phrases, err := ai.PhraseAnagrams(context.Background(), "Лёка", 1, 0)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(phrases, [][]string{{"елка"}, {"кале"}}) {
+		t.Errorf("got %v, want [[елка] [кале]]", phrases)
+	}
+}
+
+func TestFindOrderedAnagramSets(t *testing.T) {
+	input := []string{"Сон", "кот", "нос", "ТОК", "сон", "лес", "ток", "СОН", "окт"}
+	expected := []AnagramSet{
+		{Key: "Сон", Words: []string{"нос", "сон"}, FirstIndex: 0, Forms: []string{"Сон", "нос", "сон", "СОН"}},
+		{Key: "кот", Words: []string{"кот", "окт", "ток"}, FirstIndex: 1, Forms: []string{"кот", "ТОК", "ток", "окт"}},
+	}
+
+	for i := 0; i < 10; i++ {
+		actual := FindOrderedAnagramSets(input, nil)
+
+		if !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("got %v, want %v", actual, expected)
+		}
+	}
+
+	if actual := FindOrderedAnagramSets(nil, nil); len(actual) != 0 {
+		t.Errorf("got %v, want empty result", actual)
+	}
+}
+
+// sendWords возвращает канал, в который в отдельной горутине отправляются слова words
+func sendWords(words []string) <-chan string {
+	channel := make(chan string)
+
+	go func() {
+		defer close(channel)
+
+		for _, word := range words {
+			channel <- word
+		}
+	}()
+
+	return channel
+}
+
+func TestFindAnagramSetsStream(t *testing.T) {
+	words := generateWords(20000)
+	normalizer, _ := NewNormalizer(NormalizeOptions{FoldDiacritics: true})
+	// приведение к нижнему регистру по правилам языка хранит состояние, одновременное использование из горутин
+	// нормализации проверяется запуском go test -race
+	localeNormalizer, _ := NewNormalizer(NormalizeOptions{Locale: "tr", FoldDiacritics: true})
+
+	tests := []struct {
+		name    string
+		options ParallelOptions
+	}{
+		{name: "One worker", options: ParallelOptions{Workers: 1}},
+		{name: "Several workers", options: ParallelOptions{Workers: 4}},
+		{name: "Workers by CPU count", options: ParallelOptions{}},
+		{name: "With normalizer", options: ParallelOptions{Workers: 3, Normalizer: normalizer}},
+		{name: "With locale", options: ParallelOptions{Workers: 8, Normalizer: localeNormalizer}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected := FindOrderedAnagramSets(words, tt.options.Normalizer)
+
+			actual, err := FindAnagramSetsStream(context.Background(), sendWords(words), tt.options)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(actual, expected) {
+				t.Errorf("FindAnagramSetsStream differs from FindOrderedAnagramSets")
+			}
+		})
+	}
+}
+
+func TestFindAnagramSetsStream_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// канал не закрывается, поэтому поиск может завершиться только отменой контекста
+	_, err := FindAnagramSetsStream(ctx, make(chan string), ParallelOptions{Workers: 2})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestFindAnagramSetsReader(t *testing.T) {
+	input := "Сон\n  кот \n\nнос\nТОК\nсон\nлес\nток\nСОН\nокт\n"
+	expected := []AnagramSet{
+		{Key: "Сон", Words: []string{"нос", "сон"}, FirstIndex: 0, Forms: []string{"Сон", "нос", "сон", "СОН"}},
+		{Key: "кот", Words: []string{"кот", "окт", "ток"}, FirstIndex: 1, Forms: []string{"кот", "ТОК", "ток", "окт"}},
+	}
+
+	actual, err := FindAnagramSetsReader(context.Background(), strings.NewReader(input), ParallelOptions{Workers: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("got %v, want %v", actual, expected)
+	}
+
+	_, err = FindAnagramSetsReader(context.Background(), iotest.ErrReader(io.ErrUnexpectedEOF), ParallelOptions{})
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("got error %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
+func BenchmarkFindAnagramSetsStream(b *testing.B) {
+	words := generateWords(100000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, _ = FindAnagramSetsStream(context.Background(), sendWords(words), ParallelOptions{})
+	}
+}
+
+func TestDeletionVariants(t *testing.T) {
+	tests := []struct {
+		word     string
+		k        int
+		expected []string
+	}{
+		{word: "кот", k: 0, expected: []string{"кот"}},
+		{word: "кот", k: 1, expected: []string{"кот", "от", "кт", "ко"}},
+		{word: "ааб", k: 2, expected: []string{"ааб", "аб", "б", "а", "аа"}},
+		{word: "аб", k: 3, expected: []string{"аб", "б", "", "а"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			result := deletionVariants(sortedClusters(tt.word), tt.k)
+
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("got %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestClusterDistance(t *testing.T) {
+	tests := []struct {
+		word1, word2 string
+		expected     int
+	}{
+		{word1: "пятак", word2: "тяпка", expected: 0},
+		{word1: "пятак", word2: "пятаки", expected: 1},
+		{word1: "пятак", word2: "пятка", expected: 0},
+		{word1: "пятак", word2: "пяток", expected: 1},
+		{word1: "пятак", word2: "пят", expected: 2},
+		{word1: "кот", word2: "пёс", expected: 3},
+		{word1: "", word2: "ab", expected: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word1+" "+tt.word2, func(t *testing.T) {
+			result := clusterDistance(sortedClusters(tt.word1), sortedClusters(tt.word2))
+			if result != tt.expected {
+				t.Errorf("got %d, want %d", result, tt.expected)
+			}
+
+			if reverse := clusterDistance(sortedClusters(tt.word2), sortedClusters(tt.word1)); reverse != result {
+				t.Errorf("distance is not symmetric: %d and %d", result, reverse)
+			}
+		})
+	}
+}
+
+func TestFindNearAnagramSets(t *testing.T) {
+	input := []string{"Пятак", "тяпка", "пятаки", "кот", "пяток", "ток", "пят", "кто", "коты", "лес"}
+
+	tests := []struct {
+		name        string
+		maxDistance int
+		expected    []AnagramSet
+	}{
+		{
+			name:        "Exact anagrams",
+			maxDistance: 0,
+			expected: []AnagramSet{
+				{
+					Key:        "Пятак",
+					Words:      []string{"пятак", "тяпка"},
+					FirstIndex: 0,
+					Forms:      []string{"Пятак", "тяпка"},
+					Distances:  []int{0, 0},
+				},
+				{
+					Key:        "кот",
+					Words:      []string{"кот", "кто", "ток"},
+					FirstIndex: 3,
+					Forms:      []string{"кот", "ток", "кто"},
+					Distances:  []int{0, 0, 0},
+				},
+			},
+		},
+		{
+			name:        "One edit",
+			maxDistance: 1,
+			expected: []AnagramSet{
+				{
+					Key:        "Пятак",
+					Words:      []string{"пятак", "пятаки", "пяток", "тяпка"},
+					FirstIndex: 0,
+					Forms:      []string{"Пятак", "тяпка", "пятаки", "пяток"},
+					Distances:  []int{0, 1, 1, 0},
+				},
+				{
+					Key:        "кот",
+					Words:      []string{"кот", "коты", "кто", "ток"},
+					FirstIndex: 3,
+					Forms:      []string{"кот", "ток", "кто", "коты"},
+					Distances:  []int{0, 1, 0, 0},
+				},
+			},
+		},
+		{
+			name:        "Two edits",
+			maxDistance: 2,
+			expected: []AnagramSet{
+				{
+					Key:        "Пятак",
+					Words:      []string{"пят", "пятак", "пятаки", "пяток", "тяпка"},
+					FirstIndex: 0,
+					Forms:      []string{"Пятак", "тяпка", "пятаки", "пяток", "пят"},
+					Distances:  []int{2, 0, 1, 1, 0},
+				},
+				{
+					Key:        "кот",
+					Words:      []string{"кот", "коты", "кто", "ток"},
+					FirstIndex: 3,
+					Forms:      []string{"кот", "ток", "кто", "коты"},
+					Distances:  []int{0, 1, 0, 0},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := FindNearAnagramSets(input, nil, tt.maxDistance)
+
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("got %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+// findNearAnagramSetsReference эталонная реализация жадного построения множеств близких анаграмм, которая сравнивает
+// каждое множество точных анаграмм со всеми следующими, возвращает мапу ключей в слова множеств
+func findNearAnagramSetsReference(words []string, maxDistance int) map[string][]string {
+	groups, _ := groupAnagrams(words, nil)
+	assigned := make([]bool, len(groups))
+	result := make(map[string][]string)
+
+	for i, center := range groups {
+		if assigned[i] {
+			continue
+		}
+
+		members := slices.Clone(center.Words)
+
+		for j := i + 1; j < len(groups); j++ {
+			distance := clusterDistance(sortedClusters(center.Words[0]), sortedClusters(groups[j].Words[0]))
+			if !assigned[j] && distance <= maxDistance {
+				assigned[j] = true
+				members = append(members, groups[j].Words...)
+			}
+		}
+
+		if len(members) > 1 {
+			slices.Sort(members)
+			result[center.Key] = members
+		}
+	}
+
+	return result
+}
+
+func TestFindNearAnagramSets_MatchesReference(t *testing.T) {
+	words := generateWords(3000)
+
+	for maxDistance := 0; maxDistance <= 2; maxDistance++ {
+		expected := findNearAnagramSetsReference(words, maxDistance)
+		result := make(map[string][]string)
+
+		for _, set := range FindNearAnagramSets(words, nil, maxDistance) {
+			result[set.Key] = set.Words
+		}
+
+		if !reflect.DeepEqual(result, expected) {
+			t.Errorf("FindNearAnagramSets differs from the reference implementation with distance %d", maxDistance)
+		}
+	}
+}
+
+func TestLRUCache(t *testing.T) {
+	cache := newLRUCache[string, int](2)
+	cache.put("a", 1)
+	cache.put("b", 2)
+
+	// обращение к "a" делает самым давно использованным значение "b", которое вытесняется при добавлении "c"
+	if value, ok := cache.get("a"); !ok || value != 1 {
+		t.Errorf("got %d, %v, want 1, true", value, ok)
+	}
+
+	cache.put("c", 3)
+
+	if _, ok := cache.get("b"); ok {
+		t.Errorf("least recently used value was not evicted")
+	}
+
+	if value, ok := cache.get("c"); !ok || value != 3 {
+		t.Errorf("got %d, %v, want 3, true", value, ok)
+	}
+
+	disabled := newLRUCache[string, int](0)
+	disabled.put("a", 1)
+
+	if _, ok := disabled.get("a"); ok {
+		t.Errorf("disabled cache stored a value")
+	}
+}
+
+func TestAnagramServer(t *testing.T) {
+	server := httptest.NewServer(NewAnagramServer(ServerOptions{CacheSize: 16, MaxBodySize: 1024}).Handler())
+	defer server.Close()
+
+	// запросы выполняются по очереди, так как ответы зависят от загруженного словаря
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		status   int
+		expected string
+	}{
+		{
+			name:     "Query before loading",
+			method:   http.MethodGet,
+			path:     "/anagrams?word=пятак",
+			status:   http.StatusServiceUnavailable,
+			expected: `{"error":"dictionary is not loaded"}`,
+		},
+		{
+			name:     "Load dictionary",
+			method:   http.MethodPost,
+			path:     "/load",
+			body:     "Пятак\nпятка\nтяпка\nлисток\nслиток\nсон\nнос\nлес\n",
+			status:   http.StatusOK,
+			expected: `{"result":{"words":8,"unique":8,"sets":3,"anagrams":7,"largest_set":3}}`,
+		},
+		{
+			name:     "Query anagrams",
+			method:   http.MethodGet,
+			path:     "/anagrams?word=ТЯПКА",
+			status:   http.StatusOK,
+			expected: `{"result":{"key":"ТЯПКА","words":["пятак","пятка"]}}`,
+		},
+		{
+			name:     "Query cached anagrams",
+			method:   http.MethodGet,
+			path:     "/anagrams?word=ТЯПКА",
+			status:   http.StatusOK,
+			expected: `{"result":{"key":"ТЯПКА","words":["пятак","пятка"]}}`,
+		},
+		{
+			name:     "Query word without anagrams",
+			method:   http.MethodGet,
+			path:     "/anagrams?word=лес",
+			status:   http.StatusOK,
+			expected: `{"result":{"key":"лес","words":[]}}`,
+		},
+		{
+			name:   "List sets by size",
+			method: http.MethodGet,
+			path:   "/sets?sort_by=size&limit=2",
+			status: http.StatusOK,
+			expected: `{"result":{"total":3,"offset":0,"limit":2,"sets":[` +
+				`{"key":"Пятак","words":["пятак","пятка","тяпка"],"first_index":0,"forms":["Пятак","пятка","тяпка"]},` +
+				`{"key":"листок","words":["листок","слиток"],"first_index":3,"forms":["листок","слиток"]}]}}`,
+		},
+		{
+			name:   "List second page",
+			method: http.MethodGet,
+			path:   "/sets?sort_by=size&limit=2&offset=2",
+			status: http.StatusOK,
+			expected: `{"result":{"total":3,"offset":2,"limit":2,"sets":[` +
+				`{"key":"сон","words":["нос","сон"],"first_index":5,"forms":["сон","нос"]}]}}`,
+		},
+		{
+			name:     "List page after the end",
+			method:   http.MethodGet,
+			path:     "/sets?offset=10",
+			status:   http.StatusOK,
+			expected: `{"result":{"total":3,"offset":10,"limit":20,"sets":[]}}`,
+		},
+		{
+			name:   "List large sets",
+			method: http.MethodGet,
+			path:   "/sets?min_size=3&sort_by=first",
+			status: http.StatusOK,
+			expected: `{"result":{"total":1,"offset":0,"limit":20,"sets":[` +
+				`{"key":"Пятак","words":["пятак","пятка","тяпка"],"first_index":0,` +
+				`"forms":["Пятак","пятка","тяпка"]}]}}`,
+		},
+		{
+			name:     "Reload dictionary in text mode",
+			method:   http.MethodPost,
+			path:     "/load?mode=text",
+			body:     "Кот, ток и окт.",
+			status:   http.StatusOK,
+			expected: `{"result":{"words":4,"unique":4,"sets":1,"anagrams":3,"largest_set":3}}`,
+		},
+		{
+			name:     "Query after reload",
+			method:   http.MethodGet,
+			path:     "/anagrams?word=ТЯПКА",
+			status:   http.StatusOK,
+			expected: `{"result":{"key":"ТЯПКА","words":[]}}`,
+		},
+		{
+			name:     "Missing word",
+			method:   http.MethodGet,
+			path:     "/anagrams?word=+",
+			status:   http.StatusBadRequest,
+			expected: `{"error":"missing word parameter"}`,
+		},
+		{
+			name:     "Invalid limit",
+			method:   http.MethodGet,
+			path:     "/sets?limit=0",
+			status:   http.StatusBadRequest,
+			expected: `{"error":"invalid limit, expected integer from 1 to 1000"}`,
+		},
+		{
+			name:     "Invalid offset",
+			method:   http.MethodGet,
+			path:     "/sets?offset=first",
+			status:   http.StatusBadRequest,
+			expected: `{"error":"invalid offset, expected non-negative integer"}`,
+		},
+		{
+			name:     "Invalid sort order",
+			method:   http.MethodGet,
+			path:     "/sets?sort_by=length",
+			status:   http.StatusBadRequest,
+			expected: `{"error":"unknown sort order, expected key, size or first"}`,
+		},
+		{
+			name:     "Invalid mode",
+			method:   http.MethodPost,
+			path:     "/load?mode=csv",
+			status:   http.StatusBadRequest,
+			expected: `{"error":"unknown input mode, expected lines or text"}`,
+		},
+		{
+			name:     "Too large dictionary",
+			method:   http.MethodPost,
+			path:     "/load",
+			body:     strings.Repeat("пятак\n", 200),
+			status:   http.StatusRequestEntityTooLarge,
+			expected: `{"error":"dictionary is too large"}`,
+		},
+		{
+			name:     "Wrong method",
+			method:   http.MethodGet,
+			path:     "/load",
+			status:   http.StatusMethodNotAllowed,
+			expected: `{"error":"method not allowed"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := http.NewRequest(tt.method, server.URL+tt.path, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			response, err := server.Client().Do(request)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			body, err := io.ReadAll(response.Body)
+			_ = response.Body.Close()
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if response.StatusCode != tt.status {
+				t.Errorf("got status %d, want %d", response.StatusCode, tt.status)
+			}
+
+			if result := strings.TrimSpace(string(body)); result != tt.expected {
+				t.Errorf("got %s, want %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnagramServer_Concurrent(t *testing.T) {
+	// нормализатор с языком хранит состояние, одновременные запросы проверяются запуском go test -race
+	normalizer, err := NewNormalizer(NormalizeOptions{Locale: "tr", FoldDiacritics: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	anagramServer := NewAnagramServer(ServerOptions{Normalizer: normalizer, CacheSize: 16})
+	anagramServer.Load([]string{"Пятак", "пятка", "тяпка", "İnce", "cine"})
+
+	server := httptest.NewServer(anagramServer.Handler())
+	defer server.Close()
+
+	words := []string{"пятак", "ПЯТАК", "Пятак", "ince", "İNCE"}
+	expected := map[string]string{
+		"пятак": `{"result":{"key":"пятак","words":["пятка","тяпка"]}}`,
+		"ПЯТАК": `{"result":{"key":"ПЯТАК","words":["пятка","тяпка"]}}`,
+		"Пятак": `{"result":{"key":"Пятак","words":["пятка","тяпка"]}}`,
+		"ince":  `{"result":{"key":"ince","words":["cine"]}}`,
+		"İNCE":  `{"result":{"key":"İNCE","words":["cine"]}}`,
+	}
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, 16)
+
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+
+		go func(word string) {
+			defer wg.Done()
+
+			response, err := server.Client().Get(server.URL + "/anagrams?word=" + url.QueryEscape(word))
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			body, err := io.ReadAll(response.Body)
+			_ = response.Body.Close()
+
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			if result := strings.TrimSpace(string(body)); result != expected[word] {
+				errs <- fmt.Errorf("word %s: got %s, want %s", word, result, expected[word])
+			}
+		}(words[i%len(words)])
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+
+	// разные написания слова занимают одну запись кеша
+	dictionary := anagramServer.dictionary.Load()
+	if size := dictionary.lookups.order.Len(); size != 2 {
+		t.Errorf("got %d cached lookups, want 2", size)
+	}
+}
+
+func TestAnagramServer_Serve(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	server := NewAnagramServer(ServerOptions{})
+	server.Load([]string{"пятак", "тяпка"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	serveErr := make(chan error, 1)
+
+	go func() {
+		serveErr <- server.Serve(ctx, listener)
+	}()
+
+	response, err := http.Get("http://" + listener.Addr().String() + "/anagrams?word=пятак")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_ = response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		t.Errorf("got status %d, want %d", response.StatusCode, http.StatusOK)
+	}
+
+	// после отмены контекста сервер завершает работу без ошибки и перестает принимать соединения
+	cancel()
+
+	if err := <-serveErr; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := http.Get("http://" + listener.Addr().String() + "/anagrams?word=пятак"); err == nil {
+		t.Errorf("server accepts connections after shutdown")
+	}
+}
+
+func TestLexicon_IsPalindrome(t *testing.T) {
+	words := lexicon.New(nil, nil)
+
+	tests := []struct {
+		word     string
+		expected bool
+	}{
+		{word: "Шалаш", expected: true},
+		{word: "level", expected: true},
+		{word: "а", expected: true},
+		{word: "шалаши", expected: false},
+		{word: "e\u0301te\u0301", expected: true},
+		{word: "e\u0301te", expected: false},
+		{word: "a\u200da", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if result := words.IsPalindrome(tt.word); result != tt.expected {
+				t.Errorf("got %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLexicon_IsPhrasePalindrome(t *testing.T) {
+	normalizer, _ := NewNormalizer(NormalizeOptions{FoldDiacritics: true})
+
+	tests := []struct {
+		name       string
+		normalizer *Normalizer
+		phrase     string
+		expected   bool
+	}{
+		{name: "Russian phrase", phrase: "А роза упала на лапу Азора", expected: true},
+		{name: "Punctuation", phrase: "Madam, I'm Adam!", expected: true},
+		{name: "Diacritics without folding", phrase: "Ésope reste ici et se repose", expected: false},
+		{
+			name:       "Diacritics with folding",
+			normalizer: normalizer,
+			phrase:     "Ésope reste ici et se repose",
+			expected:   true,
+		},
+		{name: "Not a palindrome", phrase: "А роза упала на лапу", expected: false},
+		{name: "Only punctuation", phrase: "?!", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			words := NewLexicon(nil, tt.normalizer)
+
+			if result := words.IsPhrasePalindrome(tt.phrase); result != tt.expected {
+				t.Errorf("got %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNewLexicon(t *testing.T) {
+	normalizer, _ := NewNormalizer(NormalizeOptions{FoldDiacritics: true})
+	words := NewLexicon([]string{"Ёж", "ЕЖ", "ёж", "", "Шалаш", "казак", "кот", "Потоп"}, normalizer)
+
+	if words.Len() != 5 {
+		t.Errorf("got %d words, want 5", words.Len())
+	}
+
+	if !words.Contains("ЁЖ") {
+		t.Errorf("folded word is not found")
+	}
+
+	expected := []string{"шалаш", "казак", "потоп"}
+	if result := words.Palindromes(2); !reflect.DeepEqual(result, expected) {
+		t.Errorf("got %v, want %v", result, expected)
+	}
+
+	// слова приводятся к нижнему регистру только нормализатором с учетом языка
+	normalizer, _ = NewNormalizer(NormalizeOptions{Locale: "tr"})
+	words = NewLexicon([]string{"ISI", "ısı", "İZİ"}, normalizer)
+
+	expected = []string{"ısı", "izi"}
+	if result := words.Palindromes(2); !reflect.DeepEqual(result, expected) {
+		t.Errorf("got %v, want %v", result, expected)
+	}
+}
+
+func TestLexicon_Ladder(t *testing.T) {
+	words := NewLexicon([]string{"cold", "cord", "card", "ward", "warm", "dog", "cat", "лёд", "мёд"}, nil)
+
+	tests := []struct {
+		name     string
+		from, to string
+		expected []string
+		err      error
+	}{
+		{name: "Shortest ladder", from: "COLD", to: "warm", expected: []string{"cold", "cord", "card", "ward", "warm"}},
+		{name: "Start word outside dictionary", from: "bold", to: "cord", expected: []string{"bold", "cold", "cord"}},
+		{name: "Same word", from: "cold", to: "Cold", expected: []string{"cold"}},
+		{name: "Diacritics", from: "лёд", to: "мёд", expected: []string{"лёд", "мёд"}},
+		{name: "Unknown end word", from: "cold", to: "heat", err: lexicon.ErrUnknownWord},
+		{name: "Different lengths", from: "dog", to: "cold", err: lexicon.ErrNoLadder},
+		{name: "No ladder", from: "dog", to: "cat", err: lexicon.ErrNoLadder},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := words.Ladder(tt.from, tt.to)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("got %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnagramClient_WriteLexicon(t *testing.T) {
+	ac := &AnagramClient{
+		flags: AnagramFlags{palindromes: true, ladder: "cold, warm"},
+		data:  []string{"Шалаш", "cold", "cord", "card", "ward", "warm", "level"},
+	}
+
+	var output strings.Builder
+
+	err := ac.WriteLexicon(&output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "шалаш\nlevel\ncold -> cord -> card -> ward -> warm\n"
+	if output.String() != expected {
+		t.Errorf("got %q, want %q", output.String(), expected)
+	}
+}
@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ReadData принимает на вход reader, возвращает слайс прочитанных строк
+func ReadData(reader io.Reader) ([]string, error) {
+	var lines []string
+
+	scanner := bufio.NewScanner(reader)
+
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// ReadWords принимает на вход reader, возвращает слайс слов, на которые разбит текст функцией Tokenize
+func ReadWords(reader io.Reader) ([]string, error) {
+	var words []string
+
+	scanner := bufio.NewScanner(reader)
+
+	for scanner.Scan() {
+		words = append(words, Tokenize(scanner.Text())...)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return words, nil
+}
+
+// WriteData принимает на вход writer и слайс данных, записывает в writer каждый элемент на отдельной строке
+func WriteData[T any](writer io.Writer, data ...T) error {
+	for _, line := range data {
+		_, err := fmt.Fprintln(writer, line)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isWordRune проверяет, может ли символ входить в слово: буквы, диакритические знаки, цифры и знак подчеркивания
+func isWordRune(r rune) bool {
+	return unicode.In(r, unicode.L, unicode.M, unicode.Nd, unicode.Pc)
+}
+
+// isMidLetter проверяет, может ли символ находиться внутри слова между буквами (апостроф, точка по центру), как
+// MidLetter и MidNumLet в UAX #29
+func isMidLetter(r rune) bool {
+	return strings.ContainsRune("'’·.:", r)
+}
+
+// isMidNum проверяет, может ли символ находиться внутри числа между цифрами, как MidNum и MidNumLet в UAX #29
+func isMidNum(r rune) bool {
+	return strings.ContainsRune(",.;'’", r)
+}
+
+// Tokenize принимает на вход текст, возвращает слайс слов, выделенных по упрощенным правилам границ слов Unicode
+// (UAX #29): слово - последовательность букв, диакритических знаков и цифр, внутри которой между буквами могут
+// стоять апостроф или точка (don't, e.g), а между цифрами - запятая или точка (3,14). Знаки препинания, пробелы и
+// прочие символы словами не считаются
+func Tokenize(text string) []string {
+	var words []string
+
+	runes := []rune(text)
+	start := -1
+
+	for i, r := range runes {
+		if isWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+
+			continue
+		}
+
+		// символ внутри слова, если с обеих сторон от него буквы (или цифры для разделителей чисел)
+		if start >= 0 && i+1 < len(runes) {
+			prev, next := runes[i-1], runes[i+1]
+
+			if isMidLetter(r) && unicode.IsLetter(prev) && unicode.IsLetter(next) {
+				continue
+			}
+
+			if isMidNum(r) && unicode.IsDigit(prev) && unicode.IsDigit(next) {
+				continue
+			}
+		}
+
+		if start >= 0 {
+			words = append(words, string(runes[start:i]))
+			start = -1
+		}
+	}
+
+	if start >= 0 {
+		words = append(words, string(runes[start:]))
+	}
+
+	return words
+}
+
+// isClusterExtend проверяет, продолжает ли символ графемный кластер: диакритические знаки, соединитель нулевой
+// ширины и вариационные селекторы. Латиница и кириллица без комбинируемых знаков проверяются без поиска по таблицам
+// Unicode, так как это основная часть слов словаря
+func isClusterExtend(r rune) bool {
+	if r < 0x300 || r >= 0x370 && r < 0x483 {
+		return false
+	}
+
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc, unicode.Variation_Selector) || r == '\u200d'
+}
+
+// NextCluster принимает на вход слово, возвращает его первый графемный кластер (символ вместе со следующими за ним
+// диакритическими знаками по упрощенным правилам UAX #29) и оставшуюся часть слова
+func NextCluster(word string) (string, string) {
+	_, size := utf8.DecodeRuneInString(word)
+
+	for size < len(word) {
+		r, n := utf8.DecodeRuneInString(word[size:])
+		if !isClusterExtend(r) {
+			break
+		}
+
+		size += n
+	}
+
+	return word[:size], word[size:]
+}
+
+// Clusters принимает на вход слово, возвращает слайс его графемных кластеров в порядке следования (см. NextCluster)
+func Clusters(word string) []string {
+	clusters := make([]string, 0, len(word))
+
+	for rest := word; rest != ""; {
+		var cluster string
+
+		cluster, rest = NextCluster(rest)
+		clusters = append(clusters, cluster)
+	}
+
+	return clusters
+}
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"bufio"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"strconv"
@@ -40,6 +42,12 @@ type GrepFlags struct {
 	invert     bool
 	fixed      bool
 	lineNum    bool
+	maxCount   int
+
+	withFilename      bool
+	noFilename        bool
+	filesWithMatches  bool
+	filesWithoutMatch bool
 }
 
 // Parse метод для распарсивания и сохранения значений флагов опций в поля структуры GrepFlags
@@ -52,6 +60,11 @@ func (gf *GrepFlags) Parse() {
 	flag.BoolVar(&gf.invert, "v", false, "Output lines in which the search pattern is not found")
 	flag.BoolVar(&gf.fixed, "F", false, "Use a regular string instead of a regular expression")
 	flag.BoolVar(&gf.lineNum, "n", false, "Output line number with the found lines")
+	flag.IntVar(&gf.maxCount, "m", -1, "Stop reading input after N found lines")
+	flag.BoolVar(&gf.withFilename, "H", false, "Print the file name for each found line")
+	flag.BoolVar(&gf.noFilename, "h", false, "Do not print file names, even when searching several files")
+	flag.BoolVar(&gf.filesWithMatches, "l", false, "Output only names of files with found lines")
+	flag.BoolVar(&gf.filesWithoutMatch, "L", false, "Output only names of files without found lines")
 
 	flag.Parse()
 }
@@ -108,14 +121,173 @@ func (ga *GrepArgs) Parse() error {
 	return nil
 }
 
+// maxLineSize наибольшая длина строки входных данных, строки длиннее bufio.MaxScanTokenSize встречаются в логах
+const maxLineSize = 1 << 30
+
+// numberedLine строка входных данных с её номером
+type numberedLine struct {
+	number int
+	text   string
+}
+
+// lineRing кольцевой буфер фиксированного размера, хранящий последние добавленные строки
+type lineRing struct {
+	lines []numberedLine
+	start int
+	size  int
+}
+
+// newLineRing конструктор для создания объекта структуры lineRing на capacity строк
+func newLineRing(capacity int) *lineRing {
+	return &lineRing{lines: make([]numberedLine, capacity)}
+}
+
+// push метод, добавляющий строку в буфер, при заполненном буфере вытесняется самая старая строка
+func (lr *lineRing) push(line numberedLine) {
+	if len(lr.lines) == 0 {
+		return
+	}
+
+	if lr.size < len(lr.lines) {
+		lr.lines[(lr.start+lr.size)%len(lr.lines)] = line
+		lr.size++
+
+		return
+	}
+
+	lr.lines[lr.start] = line
+	lr.start = (lr.start + 1) % len(lr.lines)
+}
+
+// drain метод, передающий в fn строки буфера от старой к новой и очищающий буфер
+func (lr *lineRing) drain(fn func(line numberedLine) error) error {
+	for lr.size > 0 {
+		line := lr.lines[lr.start]
+		lr.start = (lr.start + 1) % len(lr.lines)
+		lr.size--
+
+		err := fn(line)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// grepSearch состояние потокового поиска: строки обрабатываются по одной сразу после чтения, в памяти хранятся только
+// последние строки для вывода перед совпадением (опции -B, -C)
+type grepSearch struct {
+	pattern  *regexp.Regexp
+	invert   bool
+	after    int
+	maxCount int
+	lineNum  bool
+	prefix   string
+	emit     func(line string) error
+
+	// buffer последние невыведенные строки, которые выводятся, если за ними последует совпадение
+	buffer *lineRing
+	// lineNumber номер последней прочитанной строки
+	lineNumber int
+	// afterLeft количество строк после совпадения, которые еще нужно вывести
+	afterLeft int
+	// selected количество найденных строк
+	selected int
+}
+
+// finished метод, проверяющий, достигнуто ли ограничение опции -m и выведены ли строки после последнего совпадения,
+// то есть можно ли прекратить чтение входных данных
+func (gs *grepSearch) finished() bool {
+	return gs.maxCount >= 0 && gs.selected >= gs.maxCount && gs.afterLeft == 0
+}
+
+// write метод, передающий строку результата в emit с учетом опции -n и имени файла: после имени файла у найденных
+// строк ставится ":", у строк вокруг них - "-", как в GNU grep
+func (gs *grepSearch) write(line numberedLine, selected bool) error {
+	text := line.text
+
+	// учитывание опции -n
+	// если выставлен этот флаг, то к строке результата прибавляется в начале её номер в файле и ". " как сепаратор
+	// между номером и самой строкой
+	if gs.lineNum {
+		text = strconv.Itoa(line.number) + ". " + text
+	}
+
+	if gs.prefix != "" {
+		separator := "-"
+		if selected {
+			separator = ":"
+		}
+
+		text = gs.prefix + separator + text
+	}
+
+	return gs.emit(text)
+}
+
+// writeContext метод, передающий в emit строку вокруг найденной
+func (gs *grepSearch) writeContext(line numberedLine) error {
+	return gs.write(line, false)
+}
+
+// feed метод, обрабатывающий очередную строку входных данных: найденная строка выводится вместе с накопленными в
+// буфере строками до неё, строки после найденной выводятся по мере чтения, остальные строки попадают в буфер
+func (gs *grepSearch) feed(text string) error {
+	gs.lineNumber++
+	line := numberedLine{number: gs.lineNumber, text: text}
+
+	// учитывание опции -v, найденными считаются строки, в которых паттерн не найден
+	selected := gs.pattern.MatchString(text) != gs.invert
+
+	// учитывание опции -m, после достижения ограничения найденные строки не выводятся даже как контекст
+	limited := gs.maxCount >= 0 && gs.selected >= gs.maxCount
+
+	switch {
+	case selected && !limited:
+		gs.selected++
+		gs.afterLeft = gs.after
+
+		err := gs.buffer.drain(gs.writeContext)
+		if err != nil {
+			return err
+		}
+
+		return gs.write(line, true)
+	case selected:
+		gs.afterLeft = 0
+		return nil
+	case gs.afterLeft > 0:
+		gs.afterLeft--
+		return gs.writeContext(line)
+	default:
+		gs.buffer.push(line)
+		return nil
+	}
+}
+
+// search метод, построчно читающий reader до окончания данных или достижения ограничения опции -m
+func (gs *grepSearch) search(reader io.Reader) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineSize)
+
+	for !gs.finished() && scanner.Scan() {
+		err := gs.feed(scanner.Text())
+		if err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
 // GrepClient структура для управления утилитой Grep
 type GrepClient struct {
 	flags GrepFlags
 	args  GrepArgs
-	data  []string
 }
 
-// NewGrepClient конструктор для создания объекта структуры GrepClient
+// NewGrepClient конструктор для создания объекта структуры GrepClient, входные данные читаются во время поиска
 func NewGrepClient() (*GrepClient, error) {
 	// создание пустого объекта структуры GrepClient
 	gc := &GrepClient{}
@@ -127,53 +299,35 @@ func NewGrepClient() (*GrepClient, error) {
 		return nil, err
 	}
 
-	// чтение и сохранение данных для поиска в поле data структуры GrepClient, закрытие reader'ов
-	for _, inputFile := range gc.args.inputFiles {
-		partData, err := utils.ReadData(inputFile)
-		if err != nil {
-			_ = inputFile.Close()
-			return nil, err
-		}
-
-		err = inputFile.Close()
-		if err != nil {
-			return nil, err
-		}
-
-		gc.data = append(gc.data, partData...)
-	}
-
 	return gc, nil
 }
 
-// Grep метод для функционирования утилиты, который возвращает найденные по паттерну данные, исходя из установленных
-// опций при запуске утилиты
-func (gc *GrepClient) Grep() ([]string, error) {
-	var after, before int
-	var result []string
-	var err error
+// pattern метод, возвращающий регулярное выражение для поиска с учетом опций -i и -F
+func (gc *GrepClient) pattern() (*regexp.Regexp, error) {
+	pattern := gc.args.pattern.String()
 
 	// учитывание опции -i, редактирование с учетом этой опции регулярного выражения
 	if gc.flags.ignoreCase {
-		gc.args.pattern, err = regexp.Compile("(?i)" + gc.args.pattern.String())
-		if err != nil {
-			return nil, err
-		}
+		pattern = "(?i)" + pattern
 	}
 
 	// учитывание опции -F, редактирование с учетом этой опции регулярного выражения
 	if gc.flags.fixed {
-		gc.args.pattern, err = regexp.Compile("^" + gc.args.pattern.String() + "$")
-		if err != nil {
-			return nil, err
-		}
+		pattern = "^" + pattern + "$"
 	}
 
-	// длина слайса с данными для поиска
-	lenData := len(gc.data)
-	// создаем слайс []bool длиной, равной слайсу с данными для поиска, чтобы понимать по индексу слайса, какую строку
-	// из gc.data использовать, по умолчанию мы ничего не находим, то есть каждый элемент usingIndexes - false
-	usingIndexes := make([]bool, lenData)
+	return regexp.Compile(pattern)
+}
+
+// newSearch метод, возвращающий состояние потокового поиска в одном источнике данных с учетом установленных опций,
+// каждая строка результата с префиксом prefix (пустой - без префикса) передается в emit
+func (gc *GrepClient) newSearch(prefix string, emit func(line string) error) (*grepSearch, error) {
+	var after, before int
+
+	pattern, err := gc.pattern()
+	if err != nil {
+		return nil, err
+	}
 
 	// учитывание опций -A, -B, -C
 	// флаг -C в приоритете, если есть значение у -C, то after=before=gc.flags.context,
@@ -191,96 +345,195 @@ func (gc *GrepClient) Grep() ([]string, error) {
 		}
 	}
 
-	// учитывание опции -v
-	// так как ниже мы ищем данные и меняем значения в usingIndexes на противоположные (true->false, false->true),
-	// то когда выставлен флаг -v мы сразу инвертируем каждый элемент usingIndexes (сразу все считается результатом),
-	// соответсвенно когда мы действительно что-то найдем по паттерну, мы изменим значение на противоположное, таким
-	// образом добьемся инвертации результата поиска (результат - все, кроме того, что найдено)
+	// учитывание опции -v, выводятся только строки без совпадения, строки вокруг них не выводятся
 	if gc.flags.invert {
-		for i := 0; i < lenData; i++ {
-			usingIndexes[i] = !usingIndexes[i]
-		}
+		after, before = 0, 0
 	}
 
-	// осуществление поиска нужных строк по паттерну с помощью итерации циклом по слайсу данных для поиска с учетом
-	// добавления строк до и после в соответствии флагам -A, -B, -C
-	for i, str := range gc.data {
-		if gc.args.pattern.MatchString(str) {
-			for j := i - before; j < i; j++ {
-				j := j
+	search := &grepSearch{
+		pattern:  pattern,
+		invert:   gc.flags.invert,
+		after:    after,
+		maxCount: gc.flags.maxCount,
+		lineNum:  gc.flags.lineNum,
+		prefix:   prefix,
+		emit:     emit,
+		buffer:   newLineRing(before),
+	}
 
-				if j >= 0 && !usingIndexes[j] {
-					usingIndexes[j] = true
-				}
-			}
+	// учитывание опций -l, -L, для списка файлов достаточно первой найденной строки
+	if gc.flags.filesWithMatches || gc.flags.filesWithoutMatch {
+		search.after, search.maxCount, search.buffer = 0, 1, newLineRing(0)
+	}
 
-			if !usingIndexes[i] {
-				usingIndexes[i] = true
-			}
+	return search, nil
+}
 
-			if usingIndexes[i] && gc.flags.invert {
-				usingIndexes[i] = false
-			}
+// GrepInput источник данных для поиска с именем, которое выводится в результате
+type GrepInput struct {
+	Name   string
+	Reader io.Reader
+}
+
+// withFilename метод, проверяющий, нужно ли выводить имена файлов при поиске в count источниках: по умолчанию - только
+// при нескольких источниках, опция -H включает вывод, опция -h - отключает
+func (gc *GrepClient) withFilename(count int) bool {
+	switch {
+	case gc.flags.noFilename:
+		return false
+	case gc.flags.withFilename:
+		return true
+	default:
+		return count > 1
+	}
+}
 
-			for j := i + 1; j < i+after+1; j++ {
-				j := j
+// searchInput метод, построчно читающий input и передающий в emit найденные по паттерну строки и строки вокруг них
+// сразу по мере чтения. Нумерация строк, строки вокруг найденных и ограничение опции -m у каждого источника свои.
+// Возвращает количество найденных строк
+func (gc *GrepClient) searchInput(input GrepInput, withFilename bool, emit func(line string) error) (int, error) {
+	prefix := ""
+	if withFilename {
+		prefix = input.Name
+	}
 
-				if j <= lenData-1 && !usingIndexes[j] {
-					usingIndexes[j] = true
-				}
-			}
-		}
+	search, err := gc.newSearch(prefix, emit)
+	if err != nil {
+		return 0, err
 	}
 
-	// с помощью слайса usingIndexes мы в result добавляем только те элементы gc.data, значение по индексу которых в
-	// слайсе usingIndexes - true
-	for index, use := range usingIndexes {
-		if use {
-			// учитывание опции -n
-			// если выставлен этот флаг, то к строке, добавляемой в result, прибавляется в начале её номер (индекс + 1)
-			// и ". " как сепаратор между номером и самой строкой
-			if gc.flags.lineNum {
-				result = append(result, strconv.Itoa(index+1)+". "+gc.data[index])
-			} else {
-				result = append(result, gc.data[index])
-			}
+	err = search.search(input.Reader)
+	if err != nil {
+		return 0, err
+	}
+
+	return search.selected, nil
+}
+
+// Search метод, построчно читающий inputs по очереди и передающий в emit строки результата, исходя из установленных
+// опций при запуске утилиты
+func (gc *GrepClient) Search(emit func(line string) error, inputs ...GrepInput) error {
+	withFilename := gc.withFilename(len(inputs))
+
+	for _, input := range inputs {
+		_, err := gc.searchInput(input, withFilename, emit)
+		if err != nil {
+			return err
 		}
 	}
 
-	return result, nil
+	return nil
 }
 
-// Count возвращает количество найденных строк по паттерну или ошибку
-func (gc *GrepClient) Count() (int, error) {
-	searchResult, err := gc.Grep()
+// Grep метод, возвращающий найденные по паттерну в inputs данные, исходя из установленных опций при запуске утилиты
+func (gc *GrepClient) Grep(inputs ...GrepInput) ([]string, error) {
+	var result []string
+
+	err := gc.Search(func(line string) error {
+		result = append(result, line)
+		return nil
+	}, inputs...)
 	if err != nil {
-		return -1, err
+		return nil, err
 	}
 
-	lenSearchResult := len(searchResult)
+	return result, nil
+}
+
+// Count возвращает общее количество найденных строк по паттерну в inputs или ошибку. Строки вокруг найденных
+// (опции -A, -B, -C) не учитываются, как и при выводе количества опцией -c
+func (gc *GrepClient) Count(inputs ...GrepInput) (int, error) {
+	withFilename := gc.withFilename(len(inputs))
+	count := 0
+
+	for _, input := range inputs {
+		selected, err := gc.searchInput(input, withFilename, func(string) error {
+			return nil
+		})
+		if err != nil {
+			return -1, err
+		}
 
-	return lenSearchResult, nil
+		count += selected
+	}
+
+	return count, nil
 }
 
-// Start метод запуска утилиты
-func (gc *GrepClient) Start() error {
-	// учитывание опции -c
-	// в вывод идут не сами найденные строки, а количество таких строк
-	if gc.flags.count {
-		outputData, err := gc.Count()
-		err = utils.WriteData(os.Stdout, outputData)
+// Write метод, записывающий в writer результат поиска в inputs сразу по мере чтения: найденные строки, количество
+// найденных строк в каждом источнике (опция -c) или имена источников с найденными строками (опция -l) или без них
+// (опция -L)
+func (gc *GrepClient) Write(writer io.Writer, inputs ...GrepInput) error {
+	bufferedWriter := bufio.NewWriter(writer)
+	withFilename := gc.withFilename(len(inputs))
+	listFiles := gc.flags.filesWithMatches || gc.flags.filesWithoutMatch
+
+	emit := func(line string) error {
+		_, err := fmt.Fprintln(bufferedWriter, line)
+		return err
+	}
+
+	// при опциях -c, -l, -L сами строки не выводятся
+	if gc.flags.count || listFiles {
+		emit = func(string) error {
+			return nil
+		}
+	}
+
+	for _, input := range inputs {
+		selected, err := gc.searchInput(input, withFilename, emit)
 		if err != nil {
 			return err
 		}
-	} else {
-		outputData, err := gc.Grep()
-		err = utils.WriteData(os.Stdout, outputData...)
+
+		switch {
+		// учитывание опций -l, -L
+		case gc.flags.filesWithMatches && selected > 0, gc.flags.filesWithoutMatch && selected == 0:
+			err = utils.WriteData(bufferedWriter, input.Name)
+		case listFiles:
+		// учитывание опции -c
+		// в вывод идут не сами найденные строки, а количество таких строк в каждом источнике без строк вокруг них
+		case gc.flags.count && withFilename:
+			err = utils.WriteData(bufferedWriter, input.Name+":"+strconv.Itoa(selected))
+		case gc.flags.count:
+			err = utils.WriteData(bufferedWriter, selected)
+		}
+
 		if err != nil {
 			return err
 		}
 	}
 
-	return nil
+	return bufferedWriter.Flush()
+}
+
+// inputs метод, возвращающий входные файлы в виде источников данных, os.Stdin называется "(standard input)"
+func (gc *GrepClient) inputs() []GrepInput {
+	inputs := make([]GrepInput, len(gc.args.inputFiles))
+
+	for i, inputFile := range gc.args.inputFiles {
+		inputs[i] = GrepInput{Name: inputFile.Name(), Reader: inputFile}
+
+		if inputFile == os.Stdin {
+			inputs[i].Name = "(standard input)"
+		}
+	}
+
+	return inputs
+}
+
+// closeInputs метод, закрывающий входные файлы
+func (gc *GrepClient) closeInputs() {
+	for _, inputFile := range gc.args.inputFiles {
+		_ = inputFile.Close()
+	}
+}
+
+// Start метод запуска утилиты
+func (gc *GrepClient) Start() error {
+	defer gc.closeInputs()
+
+	return gc.Write(os.Stdout, gc.inputs()...)
 }
 
 func main() {
@@ -2,14 +2,24 @@ package main
 
 import (
 	"flag"
+	"io"
+	"math/rand"
 	"os"
+	"path/filepath"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 	"testing"
+	"testing/iotest"
 	"wb-level-2/develop/dev05/utils"
 )
 
+// Helper function to build a grep input from lines.
+func dataInput(data []string) GrepInput {
+	return GrepInput{Name: "data", Reader: strings.NewReader(strings.Join(data, "\n"))}
+}
+
 // Helper function to reset the command-line args.
 func resetArgs(args []string) {
 	os.Args = []string{"testArgs"}
@@ -29,46 +39,51 @@ func TestGrepFlags_Parse(t *testing.T) {
 			name: "No flags",
 			args: []string{},
 			flags: GrepFlags{
-				after:   -1,
-				before:  -1,
-				context: -1,
+				after:    -1,
+				before:   -1,
+				context:  -1,
+				maxCount: -1,
 			},
 		},
 		{
 			name: "After flag",
 			args: []string{"-A", "5"},
 			flags: GrepFlags{
-				after:   5,
-				before:  -1,
-				context: -1,
+				after:    5,
+				before:   -1,
+				context:  -1,
+				maxCount: -1,
 			},
 		},
 		{
 			name: "Before flag",
 			args: []string{"-B", "3"},
 			flags: GrepFlags{
-				after:   -1,
-				before:  3,
-				context: -1,
+				after:    -1,
+				before:   3,
+				context:  -1,
+				maxCount: -1,
 			},
 		},
 		{
 			name: "Context flag",
 			args: []string{"-C", "2"},
 			flags: GrepFlags{
-				after:   -1,
-				before:  -1,
-				context: 2,
+				after:    -1,
+				before:   -1,
+				context:  2,
+				maxCount: -1,
 			},
 		},
 		{
 			name: "Count flag",
 			args: []string{"-c"},
 			flags: GrepFlags{
-				after:   -1,
-				before:  -1,
-				context: -1,
-				count:   true,
+				after:    -1,
+				before:   -1,
+				context:  -1,
+				maxCount: -1,
+				count:    true,
 			},
 		},
 		{
@@ -78,6 +93,7 @@ func TestGrepFlags_Parse(t *testing.T) {
 				after:      -1,
 				before:     -1,
 				context:    -1,
+				maxCount:   -1,
 				ignoreCase: true,
 			},
 		},
@@ -85,39 +101,43 @@ func TestGrepFlags_Parse(t *testing.T) {
 			name: "Invert flag",
 			args: []string{"-v"},
 			flags: GrepFlags{
-				after:   -1,
-				before:  -1,
-				context: -1,
-				invert:  true,
+				after:    -1,
+				before:   -1,
+				context:  -1,
+				maxCount: -1,
+				invert:   true,
 			},
 		},
 		{
 			name: "Fixed flag",
 			args: []string{"-F"},
 			flags: GrepFlags{
-				after:   -1,
-				before:  -1,
-				context: -1,
-				fixed:   true,
+				after:    -1,
+				before:   -1,
+				context:  -1,
+				maxCount: -1,
+				fixed:    true,
 			},
 		},
 		{
 			name: "LineNum flag",
 			args: []string{"-n"},
 			flags: GrepFlags{
-				after:   -1,
-				before:  -1,
-				context: -1,
-				lineNum: true,
+				after:    -1,
+				before:   -1,
+				context:  -1,
+				maxCount: -1,
+				lineNum:  true,
 			},
 		},
 		{
 			name: "Invalid flag",
 			args: []string{"-x"},
 			flags: GrepFlags{
-				after:   -1,
-				before:  -1,
-				context: -1,
+				after:    -1,
+				before:   -1,
+				context:  -1,
+				maxCount: -1,
 			},
 		},
 	}
@@ -551,9 +571,7 @@ func TestGrepClient_Grep(t *testing.T) {
 			gc.flags.Parse()
 			err := gc.args.Parse()
 
-			gc.data = tt.data
-
-			result, err := gc.Grep()
+			result, err := gc.Grep(dataInput(tt.data))
 
 			if err != nil {
 				t.Errorf("not expected error: %q", err)
@@ -571,6 +589,7 @@ func TestGrepClient_Grep(t *testing.T) {
 }
 
 func TestGrepClient_Count(t *testing.T) {
+	// строки вокруг найденных (опции -A, -B, -C) в количество не входят, как в GNU grep -c
 	tests := []struct {
 		name           string
 		args           []string
@@ -619,7 +638,7 @@ func TestGrepClient_Count(t *testing.T) {
 				"pomelo",
 				"blueberry",
 			},
-			expectedResult: 7,
+			expectedResult: 3,
 		},
 		{
 			name: "Count searched data with before flag",
@@ -641,7 +660,7 @@ func TestGrepClient_Count(t *testing.T) {
 				"pomelo",
 				"blueberry",
 			},
-			expectedResult: 10,
+			expectedResult: 3,
 		},
 		{
 			name: "Count searched data with context flag",
@@ -663,7 +682,7 @@ func TestGrepClient_Count(t *testing.T) {
 				"pomelo",
 				"blueberry",
 			},
-			expectedResult: 10,
+			expectedResult: 3,
 		},
 		{
 			name: "Count searched data with after and context flag",
@@ -685,7 +704,7 @@ func TestGrepClient_Count(t *testing.T) {
 				"pomelo",
 				"blueberry",
 			},
-			expectedResult: 10,
+			expectedResult: 3,
 		},
 		{
 			name: "Count searched data with before and context flag",
@@ -707,7 +726,7 @@ func TestGrepClient_Count(t *testing.T) {
 				"pomelo",
 				"blueberry",
 			},
-			expectedResult: 10,
+			expectedResult: 3,
 		},
 		{
 			name: "Count searched data with after, before with context flag",
@@ -729,7 +748,7 @@ func TestGrepClient_Count(t *testing.T) {
 				"pomelo",
 				"blueberry",
 			},
-			expectedResult: 10,
+			expectedResult: 3,
 		},
 		{
 			name: "Count searched data with ignore case flag",
@@ -831,9 +850,7 @@ func TestGrepClient_Count(t *testing.T) {
 			gc.flags.Parse()
 			err := gc.args.Parse()
 
-			gc.data = tt.data
-
-			result, err := gc.Count()
+			result, err := gc.Count(dataInput(tt.data))
 
 			if err != nil {
 				t.Errorf("not expected error: %q", err)
@@ -846,6 +863,393 @@ func TestGrepClient_Count(t *testing.T) {
 	}
 }
 
+// grepReference эталонная реализация поиска по данным, загруженным в память целиком, с отметкой выводимых строк в
+// слайсе []bool длиной, равной количеству строк
+func grepReference(data []string, pattern *regexp.Regexp, before, after int, lineNum bool) []string {
+	usingIndexes := make([]bool, len(data))
+
+	for i, str := range data {
+		if !pattern.MatchString(str) {
+			continue
+		}
+
+		for j := max(i-before, 0); j <= min(i+after, len(data)-1); j++ {
+			usingIndexes[j] = true
+		}
+	}
+
+	var result []string
+
+	for index, use := range usingIndexes {
+		if use && lineNum {
+			result = append(result, strconv.Itoa(index+1)+". "+data[index])
+		} else if use {
+			result = append(result, data[index])
+		}
+	}
+
+	return result
+}
+
+func TestGrepClient_Grep_MatchesReference(t *testing.T) {
+	random := rand.New(rand.NewSource(42))
+	data := make([]string, 500)
+
+	for i := range data {
+		data[i] = []string{"apple", "berry", "pear", "cherry", "kiwi"}[random.Intn(5)]
+	}
+
+	pattern := regexp.MustCompile("er")
+
+	for before := 0; before <= 4; before++ {
+		for after := 0; after <= 4; after++ {
+			gc := GrepClient{
+				flags: GrepFlags{after: after, before: before, context: -1, lineNum: true, maxCount: -1},
+				args:  GrepArgs{pattern: pattern},
+			}
+
+			result, err := gc.Grep(dataInput(data))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if expected := grepReference(data, pattern, before, after, true); !reflect.DeepEqual(result, expected) {
+				t.Errorf("-B %d -A %d: got %v, want %v", before, after, result, expected)
+			}
+		}
+	}
+}
+
+func TestGrepClient_InvertContext(t *testing.T) {
+	data := "apple\nberry\ncherry\nkiwi\npear\nmango\nblueberry\n"
+
+	// при опции -v строки вокруг найденных не выводятся, выводятся только строки без совпадения
+	for _, flags := range []GrepFlags{
+		{after: 2, before: -1, context: -1, maxCount: -1, invert: true},
+		{after: -1, before: 2, context: -1, maxCount: -1, invert: true},
+		{after: -1, before: -1, context: 1, maxCount: -1, invert: true},
+	} {
+		gc := GrepClient{flags: flags, args: GrepArgs{pattern: regexp.MustCompile("rr")}}
+
+		result, err := gc.Grep(GrepInput{Reader: strings.NewReader(data)})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if expected := []string{"apple", "kiwi", "pear", "mango"}; !reflect.DeepEqual(result, expected) {
+			t.Errorf("-A %d -B %d -C %d: got %v, want %v", flags.after, flags.before, flags.context, result, expected)
+		}
+	}
+}
+
+func TestGrepClient_MaxCount(t *testing.T) {
+	data := "apple\nberry\nkiwi\ncherry\npear\nmango\nblueberry\nlime\n"
+
+	tests := []struct {
+		name     string
+		flags    GrepFlags
+		expected []string
+	}{
+		{
+			name:     "Stop after first match",
+			flags:    GrepFlags{after: -1, before: -1, context: -1, maxCount: 1},
+			expected: []string{"berry"},
+		},
+		{
+			name:     "Trailing context after last match",
+			flags:    GrepFlags{after: 1, before: 1, context: -1, maxCount: 2},
+			expected: []string{"apple", "berry", "kiwi", "cherry", "pear"},
+		},
+		{
+			name:     "Trailing context stops at next match",
+			flags:    GrepFlags{after: 3, before: -1, context: -1, maxCount: 1},
+			expected: []string{"berry", "kiwi"},
+		},
+		{
+			name:     "Inverted search",
+			flags:    GrepFlags{after: -1, before: -1, context: -1, maxCount: 2, invert: true},
+			expected: []string{"apple", "kiwi"},
+		},
+		{
+			name:     "Zero limit",
+			flags:    GrepFlags{after: -1, before: -1, context: -1, maxCount: 0},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gc := GrepClient{flags: tt.flags, args: GrepArgs{pattern: regexp.MustCompile("rr")}}
+
+			result, err := gc.Grep(GrepInput{Reader: strings.NewReader(data)})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("got %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGrepClient_MaxCountStopsReading(t *testing.T) {
+	gc := GrepClient{
+		flags: GrepFlags{after: -1, before: -1, context: -1, maxCount: 1},
+		args:  GrepArgs{pattern: regexp.MustCompile("er")},
+	}
+
+	// после первого совпадения чтение прекращается, поэтому ошибка чтения после найденной строки не возникает
+	reader := io.MultiReader(strings.NewReader("apple\nberry\n"), iotest.ErrReader(io.ErrUnexpectedEOF))
+	input := GrepInput{Reader: reader}
+
+	result, err := gc.Grep(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if expected := []string{"berry"}; !reflect.DeepEqual(result, expected) {
+		t.Errorf("got %v, want %v", result, expected)
+	}
+
+	gc.flags.maxCount = -1
+	input.Reader = io.MultiReader(strings.NewReader("apple\nberry\n"), iotest.ErrReader(io.ErrUnexpectedEOF))
+
+	if _, err := gc.Grep(input); err == nil {
+		t.Errorf("expected read error without -m")
+	}
+}
+
+func TestGrepClient_LongLines(t *testing.T) {
+	gc := GrepClient{
+		flags: GrepFlags{after: 1, before: -1, context: -1, maxCount: -1},
+		args:  GrepArgs{pattern: regexp.MustCompile("er")},
+	}
+
+	// строки длиннее буфера bufio.Scanner по умолчанию не должны приводить к ошибке
+	long := strings.Repeat("x", 1<<20)
+	input := GrepInput{Reader: strings.NewReader("apple\nberry\n" + long + "\n" + long + "cherry\n")}
+
+	result, err := gc.Grep(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if expected := []string{"berry", long, long + "cherry"}; !reflect.DeepEqual(result, expected) {
+		t.Errorf("got %d lines, want %d", len(result), len(expected))
+	}
+}
+
+func TestGrepClient_SearchStreams(t *testing.T) {
+	gc := GrepClient{
+		flags: GrepFlags{after: -1, before: 1, context: -1, maxCount: -1},
+		args:  GrepArgs{pattern: regexp.MustCompile("er")},
+	}
+
+	reader, writer := io.Pipe()
+	lines := make(chan string)
+	done := make(chan error, 1)
+
+	go func() {
+		done <- gc.Search(func(line string) error {
+			lines <- line
+			return nil
+		}, GrepInput{Reader: reader})
+	}()
+
+	// найденная строка и строка перед ней выводятся до окончания входных данных
+	_, _ = io.WriteString(writer, "apple\nberry\n")
+
+	for _, expected := range []string{"apple", "berry"} {
+		if line := <-lines; line != expected {
+			t.Errorf("got %q, want %q", line, expected)
+		}
+	}
+
+	_ = writer.Close()
+
+	if err := <-done; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestLineRing(t *testing.T) {
+	ring := newLineRing(2)
+
+	for i := 1; i <= 3; i++ {
+		ring.push(numberedLine{number: i, text: strconv.Itoa(i)})
+	}
+
+	var numbers []int
+
+	_ = ring.drain(func(line numberedLine) error {
+		numbers = append(numbers, line.number)
+		return nil
+	})
+
+	if expected := []int{2, 3}; !reflect.DeepEqual(numbers, expected) {
+		t.Errorf("got %v, want %v", numbers, expected)
+	}
+
+	if ring.size != 0 {
+		t.Errorf("ring is not empty after drain")
+	}
+}
+
+func TestGrepClient_MultipleFiles(t *testing.T) {
+	inputs := func() []GrepInput {
+		return []GrepInput{
+			{Name: "fruits.txt", Reader: strings.NewReader("apple\nberry\nkiwi\n")},
+			{Name: "more.txt", Reader: strings.NewReader("lime\ncherry\n")},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		flags    GrepFlags
+		inputs   []GrepInput
+		expected []string
+	}{
+		{
+			name:     "File name prefixes",
+			flags:    GrepFlags{after: -1, before: -1, context: -1, maxCount: -1},
+			inputs:   inputs(),
+			expected: []string{"fruits.txt:berry", "more.txt:cherry"},
+		},
+		{
+			name:     "Line numbers per file",
+			flags:    GrepFlags{after: -1, before: -1, context: -1, maxCount: -1, lineNum: true},
+			inputs:   inputs(),
+			expected: []string{"fruits.txt:2. berry", "more.txt:2. cherry"},
+		},
+		{
+			name:   "Context does not cross files",
+			flags:  GrepFlags{after: -1, before: -1, context: 1, maxCount: -1},
+			inputs: inputs(),
+			expected: []string{
+				"fruits.txt-apple", "fruits.txt:berry", "fruits.txt-kiwi", "more.txt-lime", "more.txt:cherry",
+			},
+		},
+		{
+			name:     "Max count per file",
+			flags:    GrepFlags{after: -1, before: -1, context: -1, maxCount: 1, invert: true},
+			inputs:   inputs(),
+			expected: []string{"fruits.txt:apple", "more.txt:lime"},
+		},
+		{
+			name:     "No file names",
+			flags:    GrepFlags{after: -1, before: -1, context: -1, maxCount: -1, noFilename: true},
+			inputs:   inputs(),
+			expected: []string{"berry", "cherry"},
+		},
+		{
+			name:     "Forced file name",
+			flags:    GrepFlags{after: -1, before: -1, context: -1, maxCount: -1, withFilename: true},
+			inputs:   inputs()[:1],
+			expected: []string{"fruits.txt:berry"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gc := GrepClient{flags: tt.flags, args: GrepArgs{pattern: regexp.MustCompile("rr")}}
+
+			result, err := gc.Grep(tt.inputs...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("got %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGrepClient_Write(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{"fruits.txt": "apple\nberry\ncherry\n", "more.txt": "lime\nkiwi\n"}
+
+	var paths []string
+
+	for _, name := range []string{"fruits.txt", "more.txt"} {
+		path := filepath.Join(dir, name)
+		paths = append(paths, path)
+
+		err := os.WriteFile(path, []byte(files[name]), 0o644)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "Found lines",
+			args:     append([]string{"-n", "rr"}, paths...),
+			expected: paths[0] + ":2. berry\n" + paths[0] + ":3. cherry\n",
+		},
+		{
+			name:     "Count per file",
+			args:     append([]string{"-c", "rr"}, paths...),
+			expected: paths[0] + ":2\n" + paths[1] + ":0\n",
+		},
+		{
+			name:     "Count per file with context",
+			args:     append([]string{"-c", "-A", "1", "-B", "1", "rr"}, paths...),
+			expected: paths[0] + ":2\n" + paths[1] + ":0\n",
+		},
+		{
+			name:     "Count in one file with context",
+			args:     []string{"-c", "-C", "2", "err", paths[0]},
+			expected: "2\n",
+		},
+		{
+			name:     "Count in one file",
+			args:     []string{"-c", "rr", paths[0]},
+			expected: "2\n",
+		},
+		{
+			name:     "Files with matches",
+			args:     append([]string{"-l", "rr"}, paths...),
+			expected: paths[0] + "\n",
+		},
+		{
+			name:     "Files without match",
+			args:     append([]string{"-L", "rr"}, paths...),
+			expected: paths[1] + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flag.CommandLine = flag.NewFlagSet(tt.name, flag.ContinueOnError)
+			resetArgs(tt.args)
+
+			gc, err := NewGrepClient()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			defer gc.closeInputs()
+
+			var output strings.Builder
+
+			err = gc.Write(&output, gc.inputs()...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if output.String() != tt.expected {
+				t.Errorf("got %q, want %q", output.String(), tt.expected)
+			}
+		})
+	}
+}
+
 func TestReadData(t *testing.T) {
 	t.Run("Read data", func(t *testing.T) {
 		input := strings.NewReader("line1\nline2\nline3")